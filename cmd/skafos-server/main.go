@@ -0,0 +1,12 @@
+// skafos-server is the dedicated server on its own, unlike the game it doesn't link opengl so it builds on a machine
+// without a display or X11
+package main
+
+import (
+	"github.com/jessehorne/skafos/server"
+	"os"
+)
+
+func main() {
+	server.Main(os.Args[1:])
+}
@@ -0,0 +1,156 @@
+package game
+
+import "math"
+
+const (
+	BiomePlains byte = 0
+	BiomeForest byte = 1
+	BiomeRocky  byte = 2
+	BiomeDesert byte = 3
+	BiomeWater  byte = 4 // lakes and rivers
+
+	// how many tiles across the temperature and moisture features are, bigger makes bigger biomes
+	TemperatureScale = 160.0
+	MoistureScale    = 120.0
+	BiomeOctaves     = 4
+
+	LakeScale  = 56.0
+	LakeLevel  = 0.32 // lake noise above this is water
+	RiverScale = 240.0
+	RiverWidth = 0.022 // river noise closer to 0 than this is water, the line where it crosses 0 winds around
+
+	SpawnDryRadius = 8 // tiles around the middle of the world that are never water so nobody spawns in it
+)
+
+// Biome decides what the ground is made of and how often things are found on it, the chances are per tile. Stone and
+// copper scattered by chance are single tiles, ores in veins are set up in Ores.
+type Biome struct {
+	Name         string
+	Ground       byte
+	TreeChance   float64
+	BushChance   float64
+	StoneChance  float64
+	CopperChance float64
+}
+
+var Biomes = map[byte]*Biome{
+	BiomePlains: {
+		Name:       "plains",
+		Ground:     BlockTypeGrass,
+		TreeChance: 0.006,
+		BushChance: 0.006,
+	},
+	BiomeForest: {
+		Name:       "forest",
+		Ground:     BlockTypeGrass,
+		TreeChance: 0.05,
+		BushChance: 0.01,
+	},
+	BiomeRocky: {
+		Name:       "rocky highlands",
+		Ground:     BlockTypeDirt,
+		TreeChance: 0.002,
+	},
+	BiomeDesert: {
+		Name:   "desert",
+		Ground: BlockTypeSand,
+	},
+	BiomeWater: {
+		Name:   "water",
+		Ground: BlockTypeWater,
+	},
+}
+
+// ChooseBiome picks the biome for a temperature and moisture, both between -1 and 1
+func ChooseBiome(temperature, moisture float64) byte {
+	if temperature < -0.18 {
+		return BiomeRocky
+	}
+	if temperature > 0.12 && moisture < 0 {
+		return BiomeDesert
+	}
+	if moisture > 0.08 {
+		return BiomeForest
+	}
+
+	return BiomePlains
+}
+
+// BiomeGenerator is a WorldGenerator that places biomes using noise sampled at every tile of the world, so biomes
+// carry on smoothly over chunk borders no matter what order chunks are generated in
+type BiomeGenerator struct {
+	Seed        uint64
+	Temperature *Noise
+	Moisture    *Noise
+	Lakes       *Noise
+	Rivers      *Noise
+}
+
+func NewBiomeGenerator(seed uint64) WorldGenerator {
+	return &BiomeGenerator{
+		Seed:        seed,
+		Temperature: NewNoise(seed, 1),
+		Moisture:    NewNoise(seed, 2),
+		Lakes:       NewNoise(seed, 3),
+		Rivers:      NewNoise(seed, 4),
+	}
+}
+
+// BiomeIDAt returns the id of the biome at map block coordinates x, y
+func (g *BiomeGenerator) BiomeIDAt(x, y int) byte {
+	t := g.Temperature.Fractal(float64(x)/TemperatureScale, float64(y)/TemperatureScale, BiomeOctaves)
+	m := g.Moisture.Fractal(float64(x)/MoistureScale, float64(y)/MoistureScale, BiomeOctaves)
+
+	if g.IsWater(x, y, t, m) {
+		return BiomeWater
+	}
+
+	return ChooseBiome(t, m)
+}
+
+// IsWater returns true if there's a lake or river at map block coordinates x, y, which have temperature t and
+// moisture m. Lakes don't form in deserts but rivers run through everything.
+func (g *BiomeGenerator) IsWater(x, y int, t, m float64) bool {
+	if abs(x) <= SpawnDryRadius && abs(y) <= SpawnDryRadius {
+		return false
+	}
+
+	river := g.Rivers.Fractal(float64(x)/RiverScale, float64(y)/RiverScale, 3)
+	if math.Abs(river) < RiverWidth {
+		return true
+	}
+
+	if ChooseBiome(t, m) == BiomeDesert {
+		return false
+	}
+
+	return g.Lakes.Fractal(float64(x)/LakeScale, float64(y)/LakeScale, 3) > LakeLevel
+}
+
+// BiomeAt returns the biome at map block coordinates x, y
+func (g *BiomeGenerator) BiomeAt(x, y int) *Biome {
+	return Biomes[g.BiomeIDAt(x, y)]
+}
+
+func (g *BiomeGenerator) Name() string {
+	return WorldGeneratorBiomes
+}
+
+func (g *BiomeGenerator) GenerateChunk(x, y, w, h int) *Chunk {
+	c := NewChunk(x, y, w, h, g.Seed, g.BiomeAt)
+	PlaceOres(c, g.Seed, g.BiomeIDAt)
+	PlaceStructures(c, g.Seed, g.BiomeIDAt)
+
+	return c
+}
+
+// GetBiomeIDByName returns the id of the biome called name
+func GetBiomeIDByName(name string) (byte, bool) {
+	for id, b := range Biomes {
+		if b.Name == name {
+			return id, true
+		}
+	}
+
+	return 0, false
+}
@@ -2,15 +2,28 @@ package game
 
 import (
 	"github.com/gopxl/pixel/v2"
-	"github.com/gopxl/pixel/v2/backends/opengl"
+	"math/rand/v2"
 )
 
+// block ids, these have to match the ids in BlockDefinitionsPath
 const (
-	BlockTypeDirt   byte = 0
-	BlockTypeGrass  byte = 1
-	BlockTypeTree   byte = 2
-	BlockTypeStone  byte = 3
-	BlockTypeCopper byte = 4
+	BlockTypeDirt    byte = 0
+	BlockTypeGrass   byte = 1
+	BlockTypeTree    byte = 2
+	BlockTypeStone   byte = 3
+	BlockTypeCopper  byte = 4
+	BlockTypeWood    byte = 5
+	BlockTypeBrick   byte = 6
+	BlockTypeBush    byte = 7
+	BlockTypeBed     byte = 8
+	BlockTypeChest   byte = 9
+	BlockTypeFurnace byte = 10
+	BlockTypeSapling byte = 11
+	BlockTypeSand    byte = 12
+	BlockTypeCoal    byte = 13
+	BlockTypeIron    byte = 14
+	BlockTypeWater   byte = 15
+	BlockTypeWall    byte = 16
 
 	BlockTypeDirtFrameDirt byte = 0
 
@@ -26,21 +39,86 @@ const (
 	BlockTypeStoneFrame1 byte = 0
 
 	BlockTypeCopperFrame1 byte = 0
+
+	BlockTypeWoodFrame1 byte = 0
+
+	BlockTypeBrickFrameStone byte = 0
+	BlockTypeBrickFrameMossy byte = 1
+
+	BlockTypeBushFrameBare    byte = 0
+	BlockTypeBushFrameBerries byte = 1
+
+	BlockTypeBedFrame1 byte = 0
+
+	BlockTypeChestFrame1 byte = 0
+
+	BlockTypeFurnaceFrame1 byte = 0
+
+	BlockTypeSaplingFrame1 byte = 0
+
+	BlockTypeSandFrame1 byte = 0
+
+	BlockTypeCoalFrame1 byte = 0
+
+	BlockTypeIronFrame1 byte = 0
+
+	BlockTypeWaterFrame1 byte = 0
+
+	BlockTypeWallFrame1 byte = 0
 )
 
 type Block struct {
 	Position  pixel.Vec
 	Type      byte
 	Frame     byte
+	Damage    float64     // how many hits the block has taken, hits with the right tool count for more
+	Entity    BlockEntity // nil for blocks without their own state
 	DebugRect *pixel.Sprite
 }
 
-func NewBlock(win *opengl.Window, blockType, frame byte, pos pixel.Vec) *Block {
+func NewBlock(blockType, frame byte, pos pixel.Vec) *Block {
 	return &Block{
 		Type:      blockType,
 		Frame:     frame,
 		Position:  pos,
-		DebugRect: MakeDebugRect(win, 16, 16),
+		Entity:    NewBlockEntity(blockType),
+		DebugRect: MakeDebugRect(16, 16),
+	}
+}
+
+// Hit damages the block with tool, nil for a bare hand, and returns true once it has been hit enough to break
+func (b *Block) Hit(tool *Tool) bool {
+	def := GetBlockDefinition(b.Type)
+	if def == nil {
+		return true
+	}
+
+	b.Damage += tool.MiningSpeed(def)
+
+	return b.Damage >= float64(def.Hardness)
+}
+
+// SpawnDrops throws whatever the block drops, and whatever its entity was holding, onto the ground where it was
+func (b *Block) SpawnDrops() {
+	if b.Entity != nil {
+		for _, item := range b.Entity.Items() {
+			velocity := pixel.V(rand.Float64()*60-30, rand.Float64()*60-30)
+			f := SpawnFloater(item.UnderlyingType, item.ItemType, item.Frame, b.Position, velocity)
+			f.Amount = item.Amount
+			f.Meta = item.Meta
+		}
+	}
+
+	def := GetBlockDefinition(b.Type)
+	if def == nil {
+		return
+	}
+
+	for _, drop := range def.DropStacks {
+		for i := 0; i < drop.Amount; i++ {
+			velocity := pixel.V(rand.Float64()*60-30, rand.Float64()*60-30)
+			SpawnFloater(drop.UnderlyingType, drop.ItemType, drop.Frame, b.Position, velocity)
+		}
 	}
 }
 
@@ -57,11 +135,21 @@ func (b *Block) Collide(c Collideable) {
 }
 
 func (b *Block) IsSolid() bool {
-	if b.Type == BlockTypeTree {
-		return true
+	def := GetBlockDefinition(b.Type)
+	if def == nil {
+		return false
+	}
+
+	return def.Solid
+}
+
+func (b *Block) IsLiquid() bool {
+	def := GetBlockDefinition(b.Type)
+	if def == nil {
+		return false
 	}
 
-	return false
+	return def.Liquid
 }
 
 func (b *Block) GetType() byte {
@@ -72,6 +160,6 @@ func (b *Block) GetOldPosition() pixel.Vec {
 	return b.Position
 }
 
-func (b *Block) DrawDebug(win *opengl.Window) {
-	b.DebugRect.Draw(win, pixel.IM.Moved(b.Position))
+func (b *Block) DrawDebug(t pixel.Target) {
+	b.DebugRect.Draw(t, pixel.IM.Moved(b.Position))
 }
@@ -0,0 +1,401 @@
+package game
+
+import "github.com/gopxl/pixel/v2"
+
+const (
+	ChestW = 8
+	ChestH = 3
+
+	// a furnace uses three slots of a small grid so they're laid out like a chest panel
+	FurnaceW       = 4
+	FurnaceH       = 2
+	FurnaceFuelX   = 1
+	FurnaceFuelY   = 0
+	FurnaceInputX  = 1
+	FurnaceInputY  = 1
+	FurnaceOutputX = 3
+	FurnaceOutputY = 1
+
+	ContainerRowOffset = 4 // an open containers rows are drawn above the players inventory rows
+)
+
+// BlockEntity is state that belongs to one block instead of its type, like what's stored in a chest
+type BlockEntity interface {
+	Items() []*InventoryItem // everything that's dropped when the block is broken
+	ToData() BlockEntityData
+	LoadData(data BlockEntityData)
+}
+
+// TickingBlockEntity is a block entity that changes over time, it's ticked as long as its chunk is loaded
+type TickingBlockEntity interface {
+	Tick(dt float64)
+}
+
+// Container is a block entity the player can open to move items in and out of
+type Container interface {
+	BlockEntity
+	Slots() [][]*InventoryItem // [y][x] with y = 0 at the bottom like the players inventory
+	HasSlot(x, y int) bool
+	CanPut(x, y int, item *InventoryItem) bool
+	Add(key ItemKey, amount int, meta ItemMeta) int // returns how many didn't fit
+}
+
+// BlockEntityData is what's saved with the chunk for a block entity
+type BlockEntityData struct {
+	Items     []InventoryItemData `json:",omitempty"`
+	Burning   float64             `json:",omitempty"`
+	BurnTotal float64             `json:",omitempty"`
+	Progress  float64             `json:",omitempty"`
+}
+
+// every block type that has an entity and how to make a new one
+var blockEntityFactories = map[byte]func() BlockEntity{
+	BlockTypeChest:   func() BlockEntity { return NewChest() },
+	BlockTypeFurnace: func() BlockEntity { return NewFurnace() },
+}
+
+// NewBlockEntity returns a new entity for a block of blockType, nil if the type doesn't have one
+func NewBlockEntity(blockType byte) BlockEntity {
+	f, ok := blockEntityFactories[blockType]
+	if !ok {
+		return nil
+	}
+
+	return f()
+}
+
+// Chest is a block entity that stores items
+type Chest struct {
+	Inventory [][]*InventoryItem // [y][x] with y = 0 at the bottom like the players inventory
+}
+
+func NewChest() *Chest {
+	c := &Chest{}
+	c.Clear()
+
+	return c
+}
+
+func (c *Chest) Clear() {
+	c.Inventory = newGrid(ChestW, ChestH)
+}
+
+func (c *Chest) Slots() [][]*InventoryItem {
+	return c.Inventory
+}
+
+func (c *Chest) HasSlot(x, y int) bool {
+	return y >= 0 && y < len(c.Inventory) && x >= 0 && x < len(c.Inventory[y])
+}
+
+func (c *Chest) CanPut(x, y int, item *InventoryItem) bool {
+	return c.HasSlot(x, y)
+}
+
+func (c *Chest) Add(key ItemKey, amount int, meta ItemMeta) int {
+	return AddItemToGrid(c.Inventory, key, amount, meta, ContainerRowOffset)
+}
+
+func (c *Chest) Items() []*InventoryItem {
+	return containerItems(c)
+}
+
+func (c *Chest) ToData() BlockEntityData {
+	return BlockEntityData{Items: containerItemData(c)}
+}
+
+func (c *Chest) LoadData(data BlockEntityData) {
+	c.Clear()
+	loadContainerItems(c, data.Items)
+}
+
+// Furnace is a block entity that burns fuel to smelt what's in its input slot into its output slot
+type Furnace struct {
+	Inventory [][]*InventoryItem // only the fuel, input and output slots are used
+	Burning   float64            // seconds left of the fuel that's burning
+	BurnTotal float64            // how many seconds the fuel that's burning lasts in total
+	Progress  float64            // seconds the input has been smelting for
+}
+
+func NewFurnace() *Furnace {
+	f := &Furnace{}
+	f.Clear()
+
+	return f
+}
+
+func (f *Furnace) Clear() {
+	f.Inventory = newGrid(FurnaceW, FurnaceH)
+	f.Burning = 0
+	f.BurnTotal = 0
+	f.Progress = 0
+}
+
+func (f *Furnace) Fuel() *InventoryItem {
+	return f.Inventory[FurnaceFuelY][FurnaceFuelX]
+}
+
+func (f *Furnace) Input() *InventoryItem {
+	return f.Inventory[FurnaceInputY][FurnaceInputX]
+}
+
+func (f *Furnace) Output() *InventoryItem {
+	return f.Inventory[FurnaceOutputY][FurnaceOutputX]
+}
+
+func (f *Furnace) Slots() [][]*InventoryItem {
+	return f.Inventory
+}
+
+func (f *Furnace) HasSlot(x, y int) bool {
+	return (x == FurnaceFuelX && y == FurnaceFuelY) ||
+		(x == FurnaceInputX && y == FurnaceInputY) ||
+		(x == FurnaceOutputX && y == FurnaceOutputY)
+}
+
+// CanPut only lets fuel into the fuel slot and things that can be smelted into the input slot, nothing can be put in
+// the output slot
+func (f *Furnace) CanPut(x, y int, item *InventoryItem) bool {
+	if x == FurnaceFuelX && y == FurnaceFuelY {
+		return FuelTime(item.Key()) > 0
+	}
+
+	if x == FurnaceInputX && y == FurnaceInputY {
+		return GetSmeltingRecipe(item.Key()) != nil
+	}
+
+	return false
+}
+
+// Add puts fuel in the fuel slot and things that can be smelted in the input slot, fuel that can also be smelted goes
+// in as fuel
+func (f *Furnace) Add(key ItemKey, amount int, meta ItemMeta) int {
+	adding := NewInventoryItem(key.UnderlyingType, key.ItemType, key.Frame, amount, pixel.ZV)
+	adding.Meta = meta
+
+	for _, slot := range [][2]int{{FurnaceFuelX, FurnaceFuelY}, {FurnaceInputX, FurnaceInputY}} {
+		x, y := slot[0], slot[1]
+		if amount <= 0 || !f.CanPut(x, y, adding) {
+			continue
+		}
+
+		item := f.Inventory[y][x]
+		if item == nil {
+			moved := min(amount, adding.MaxStack())
+			item = NewInventoryItem(key.UnderlyingType, key.ItemType, key.Frame, moved, ContainerSlotPosition(x, y))
+			item.Meta = meta.Clone()
+			f.Inventory[y][x] = item
+			amount -= moved
+		} else if item.CanStackWith(adding) {
+			moved := min(amount, item.Room())
+			item.Amount += moved
+			amount -= moved
+		}
+	}
+
+	return amount
+}
+
+// Smelting returns the recipe for what's in the input slot, nil if there's nothing to smelt or the output slot doesn't
+// have room for the result
+func (f *Furnace) Smelting() *SmeltingRecipe {
+	in := f.Input()
+	if in == nil || in.Amount <= 0 {
+		return nil
+	}
+
+	r := GetSmeltingRecipe(in.Key())
+	if r == nil {
+		return nil
+	}
+
+	out := f.Output()
+	if out == nil {
+		return r
+	}
+
+	result := NewInventoryItem(r.Result.UnderlyingType, r.Result.ItemType, r.Result.Frame, r.Result.Amount, pixel.ZV)
+	if !out.CanStackWith(result) || out.Room() < r.Result.Amount {
+		return nil
+	}
+
+	return r
+}
+
+// Tick burns fuel while there's something to smelt and moves the smelting along, a new piece of fuel is only used up
+// once the last one has burnt out
+func (f *Furnace) Tick(dt float64) {
+	r := f.Smelting()
+
+	if f.Burning <= 0 && r != nil {
+		f.burnFuel()
+	}
+
+	if f.Burning <= 0 {
+		f.Progress = 0
+		return
+	}
+
+	f.Burning = max(0, f.Burning-dt)
+
+	if r == nil {
+		f.Progress = 0
+		return
+	}
+
+	f.Progress += dt
+	if f.Progress >= r.Time {
+		f.Progress = 0
+		f.smelt(r)
+	}
+}
+
+func (f *Furnace) burnFuel() {
+	fuel := f.Fuel()
+	if fuel == nil || fuel.Amount <= 0 {
+		return
+	}
+
+	t := FuelTime(fuel.Key())
+	if t <= 0 {
+		return
+	}
+
+	fuel.Amount -= 1
+	if fuel.Amount <= 0 {
+		f.Inventory[FurnaceFuelY][FurnaceFuelX] = nil
+	}
+
+	f.Burning = t
+	f.BurnTotal = t
+}
+
+func (f *Furnace) smelt(r *SmeltingRecipe) {
+	in := f.Input()
+	in.Amount -= 1
+	if in.Amount <= 0 {
+		f.Inventory[FurnaceInputY][FurnaceInputX] = nil
+	}
+
+	if out := f.Output(); out != nil {
+		out.Amount += r.Result.Amount
+		return
+	}
+
+	f.Inventory[FurnaceOutputY][FurnaceOutputX] = NewInventoryItem(r.Result.UnderlyingType, r.Result.ItemType, r.Result.Frame, r.Result.Amount, ContainerSlotPosition(FurnaceOutputX, FurnaceOutputY))
+}
+
+// SmeltFraction returns how far along smelting the input is from 0 to 1
+func (f *Furnace) SmeltFraction() float64 {
+	r := f.Smelting()
+	if r == nil || r.Time <= 0 {
+		return 0
+	}
+
+	return min(1, f.Progress/r.Time)
+}
+
+// BurnFraction returns how much of the burning fuel is left from 0 to 1
+func (f *Furnace) BurnFraction() float64 {
+	if f.BurnTotal <= 0 {
+		return 0
+	}
+
+	return min(1, f.Burning/f.BurnTotal)
+}
+
+func (f *Furnace) Items() []*InventoryItem {
+	return containerItems(f)
+}
+
+func (f *Furnace) ToData() BlockEntityData {
+	return BlockEntityData{
+		Items:     containerItemData(f),
+		Burning:   f.Burning,
+		BurnTotal: f.BurnTotal,
+		Progress:  f.Progress,
+	}
+}
+
+func (f *Furnace) LoadData(data BlockEntityData) {
+	f.Clear()
+	loadContainerItems(f, data.Items)
+
+	f.Burning = data.Burning
+	f.BurnTotal = data.BurnTotal
+	f.Progress = data.Progress
+}
+
+func newGrid(w, h int) [][]*InventoryItem {
+	grid := [][]*InventoryItem{}
+	for y := 0; y < h; y++ {
+		grid = append(grid, make([]*InventoryItem, w))
+	}
+
+	return grid
+}
+
+// containerItems returns every stack in a containers slots
+func containerItems(c Container) []*InventoryItem {
+	items := []*InventoryItem{}
+	slots := c.Slots()
+
+	for y := 0; y < len(slots); y++ {
+		for x := 0; x < len(slots[y]); x++ {
+			if slots[y][x] != nil && slots[y][x].Amount > 0 {
+				items = append(items, slots[y][x])
+			}
+		}
+	}
+
+	return items
+}
+
+// containerItemData returns what's saved for every stack in a containers slots
+func containerItemData(c Container) []InventoryItemData {
+	data := []InventoryItemData{}
+	slots := c.Slots()
+
+	for y := 0; y < len(slots); y++ {
+		for x := 0; x < len(slots[y]); x++ {
+			item := slots[y][x]
+			if item == nil || item.Amount <= 0 {
+				continue
+			}
+
+			data = append(data, InventoryItemData{
+				UnderlyingType: item.UnderlyingType,
+				ItemType:       item.ItemType,
+				Frame:          item.Frame,
+				Amount:         item.Amount,
+				Meta:           item.Meta,
+				X:              x,
+				Y:              y,
+			})
+		}
+	}
+
+	return data
+}
+
+// loadContainerItems puts saved stacks back in a containers slots, anything saved in a slot it doesn't have is lost
+func loadContainerItems(c Container, items []InventoryItemData) {
+	slots := c.Slots()
+
+	for _, i := range items {
+		if !c.HasSlot(i.X, i.Y) {
+			continue
+		}
+
+		// positions are where the slot is drawn in the container panel, above the players inventory
+		item := NewInventoryItem(i.UnderlyingType, i.ItemType, i.Frame, i.Amount, ContainerSlotPosition(i.X, i.Y))
+		item.Meta = loadedMeta(i.UnderlyingType, i.ItemType, i.Meta)
+		slots[i.Y][i.X] = item
+	}
+}
+
+// ContainerSlotPosition returns where slot x, y of an open container is drawn, in the same coordinates as the
+// players inventory
+func ContainerSlotPosition(x, y int) pixel.Vec {
+	return pixel.V(float64(x), float64(y+ContainerRowOffset))
+}
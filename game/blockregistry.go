@@ -0,0 +1,153 @@
+package game
+
+import (
+	"fmt"
+	"github.com/gopxl/pixel/v2"
+	"math/rand/v2"
+)
+
+const (
+	BlockDefinitionsPath = "blocks.json" // in AssetsDirectory
+
+	DrawLayerFloor = "floor" // drawn under everything
+	DrawLayerTree  = "tree"  // split into a bottom drawn under the player and a top drawn over them
+)
+
+// BlockDefinition describes a type of block, they're loaded from BlockDefinitionsPath at startup
+type BlockDefinition struct {
+	ID           byte             `json:"id"`
+	Name         string           `json:"name"`
+	Frames       [][4]float64     `json:"frames"`        // x, y, w, h in the tile sheet measured from the top left
+	FrameWeights []int            `json:"frame_weights"` // how likely each frame is when generated, every frame is equally likely if empty
+	Solid        bool             `json:"solid"`
+	Liquid       bool             `json:"liquid"`    // nothing can be placed on it but it can be drunk from
+	Hardness     int              `json:"hardness"`  // how many hits it takes to break
+	Tool         string           `json:"tool"`      // the kind of tool that breaks it faster, see ToolKindNames
+	MaxStack     int              `json:"max_stack"` // how many fit in one inventory slot, 0 for the default
+	Layer        string           `json:"layer"`
+	TopFrame     byte             `json:"top_frame"`    // only used by the tree layer
+	BottomFrame  byte             `json:"bottom_frame"` // only used by the tree layer
+	Drops        []DropDefinition `json:"drops"`
+
+	Sprites    map[byte]*pixel.Sprite `json:"-"`
+	DropStacks []ItemStack            `json:"-"`
+}
+
+type DropDefinition struct {
+	Block  string `json:"block"`
+	Frame  byte   `json:"frame"`
+	Amount int    `json:"amount"`
+}
+
+var BlockDefinitions = map[byte]*BlockDefinition{}
+
+// LoadBlockDefinitions reads every block definition and cuts their sprites out of the tile sheet
+func LoadBlockDefinitions(path string, s *Spritesheet) error {
+	defs := []*BlockDefinition{}
+	if err := readJSON(path, &defs); err != nil {
+		return err
+	}
+
+	h := s.Picture.Bounds().H()
+	loaded := map[byte]*BlockDefinition{}
+	byName := map[string]*BlockDefinition{}
+
+	for _, def := range defs {
+		if _, exists := loaded[def.ID]; exists {
+			return fmt.Errorf("%s: block id %d is defined more than once", path, def.ID)
+		}
+
+		if def.Layer == "" {
+			def.Layer = DrawLayerFloor
+		}
+		if def.Hardness <= 0 {
+			def.Hardness = 1
+		}
+		if _, ok := ToolKindNames[def.Tool]; def.Tool != "" && !ok {
+			return fmt.Errorf("%s: block %q is broken by unknown tool %q", path, def.Name, def.Tool)
+		}
+
+		def.Sprites = map[byte]*pixel.Sprite{}
+		for i, f := range def.Frames {
+			def.Sprites[byte(i)] = pixel.NewSprite(s.Picture, pixel.R(f[0], h-f[1], f[0]+f[2], h-f[1]-f[3]))
+		}
+
+		loaded[def.ID] = def
+		byName[def.Name] = def
+	}
+
+	// drops refer to blocks by name so they can only be resolved once everything is loaded
+	for _, def := range loaded {
+		def.DropStacks = []ItemStack{}
+		for _, d := range def.Drops {
+			dropDef, ok := byName[d.Block]
+			if !ok {
+				return fmt.Errorf("%s: block %q drops unknown block %q", path, def.Name, d.Block)
+			}
+
+			def.DropStacks = append(def.DropStacks, ItemStack{
+				UnderlyingType: UnderlyingTypePlaceableBlock,
+				ItemType:       dropDef.ID,
+				Frame:          d.Frame,
+				Amount:         d.Amount,
+			})
+		}
+	}
+
+	BlockDefinitions = loaded
+
+	return nil
+}
+
+func GetBlockDefinition(blockType byte) *BlockDefinition {
+	return BlockDefinitions[blockType]
+}
+
+// GetBlockTypeByName returns the id of the block called name
+func GetBlockTypeByName(name string) (byte, bool) {
+	for id, def := range BlockDefinitions {
+		if def.Name == name {
+			return id, true
+		}
+	}
+
+	return 0, false
+}
+
+// Sprite returns the sprite for a frame, falling back to the first frame
+func (d *BlockDefinition) Sprite(frame byte) *pixel.Sprite {
+	sprite, ok := d.Sprites[frame]
+	if !ok {
+		return d.Sprites[0]
+	}
+
+	return sprite
+}
+
+// RandomFrame picks a frame using FrameWeights
+func (d *BlockDefinition) RandomFrame(rnd *rand.Rand) byte {
+	if len(d.FrameWeights) == 0 {
+		if len(d.Frames) == 0 {
+			return 0
+		}
+		return byte(rnd.IntN(len(d.Frames)))
+	}
+
+	total := 0
+	for _, w := range d.FrameWeights {
+		total += w
+	}
+	if total <= 0 {
+		return 0
+	}
+
+	n := rnd.IntN(total)
+	for i, w := range d.FrameWeights {
+		if n < w {
+			return byte(i)
+		}
+		n -= w
+	}
+
+	return 0
+}
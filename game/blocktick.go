@@ -0,0 +1,187 @@
+package game
+
+import "math/rand/v2"
+
+const (
+	RandomTicksPerChunk = 3.0 // how many random blocks in every loaded chunk get a random tick each second
+
+	SaplingGrowTimeMin = 180.0 // seconds before a planted sapling tries to grow into a tree
+	SaplingGrowTimeMax = 420.0
+	SaplingRetryTime   = 30.0 // seconds before a sapling that didn't have room tries again
+
+	BushRegrowChance = 0.02 // chance a random tick grows the berries back on a bare bush
+)
+
+// ScheduledTick runs the tick of the block at X, Y in a chunk once Delay seconds have passed, as long as a block of
+// Type is still on top there
+type ScheduledTick struct {
+	X     int
+	Y     int
+	Type  byte
+	Delay float64
+}
+
+// BlockTicker is how a type of block changes over time, coords are the blocks position in chunk c. Ticks return true
+// if they changed the chunk.
+type BlockTicker struct {
+	OnPlace    func(m *Map, c *Chunk, coords IntVec, b *Block)      // called once the block is placed, usually to schedule its first tick
+	Tick       func(m *Map, c *Chunk, coords IntVec, b *Block) bool // called when a scheduled tick is due
+	RandomTick func(m *Map, c *Chunk, coords IntVec, b *Block) bool // called when the block is picked for a random tick
+}
+
+var blockTickers = map[byte]*BlockTicker{
+	BlockTypeSapling: {
+		OnPlace: func(m *Map, c *Chunk, coords IntVec, b *Block) {
+			c.ScheduleTick(coords, b.Type, SaplingGrowTimeMin+rand.Float64()*(SaplingGrowTimeMax-SaplingGrowTimeMin))
+		},
+		Tick: growSapling,
+	},
+	BlockTypeBush: {
+		RandomTick: regrowBerries,
+	},
+}
+
+// ScheduleTick makes the block of blockType at coords tick after delay seconds
+func (c *Chunk) ScheduleTick(coords IntVec, blockType byte, delay float64) {
+	c.Ticks = append(c.Ticks, &ScheduledTick{X: coords.X, Y: coords.Y, Type: blockType, Delay: delay})
+}
+
+// TickBlocks runs the scheduled ticks that are due and random ticks in every loaded chunk, it returns the position of
+// every chunk that was changed
+func (m *Map) TickBlocks(dt float64) []IntVec {
+	m.randomTicks += dt * RandomTicksPerChunk
+	randomTicks := int(m.randomTicks)
+	m.randomTicks -= float64(randomTicks)
+
+	changed := []IntVec{}
+
+	for _, row := range m.Chunks {
+		for _, c := range row {
+			ticked := m.runScheduledTicks(c, dt)
+
+			for i := 0; i < randomTicks; i++ {
+				if m.randomTick(c) {
+					ticked = true
+				}
+			}
+
+			if ticked {
+				c.Dirty = true
+				changed = append(changed, NewIntVec(c.X, c.Y))
+			}
+		}
+	}
+
+	return changed
+}
+
+// runScheduledTicks counts down the chunks scheduled ticks and runs the ones that are due, it returns true if any ran
+func (m *Map) runScheduledTicks(c *Chunk, dt float64) bool {
+	due := []*ScheduledTick{}
+	waiting := []*ScheduledTick{}
+
+	for _, t := range c.Ticks {
+		t.Delay -= dt
+		if t.Delay <= 0 {
+			due = append(due, t)
+		} else {
+			waiting = append(waiting, t)
+		}
+	}
+
+	// ticks can schedule more ticks so the list has to be settled before any run
+	c.Ticks = waiting
+
+	ran := false
+	for _, t := range due {
+		coords := NewIntVec(t.X, t.Y)
+
+		b := c.TopBlock(coords)
+		if b == nil || b.Type != t.Type {
+			continue
+		}
+
+		ticker, ok := blockTickers[b.Type]
+		if !ok || ticker.Tick == nil {
+			continue
+		}
+
+		if ticker.Tick(m, c, coords, b) {
+			ran = true
+		}
+	}
+
+	return ran
+}
+
+// randomTick gives the top block at a random spot in the chunk a random tick, it returns true if it changed the chunk
+func (m *Map) randomTick(c *Chunk) bool {
+	coords := NewIntVec(rand.IntN(c.W), rand.IntN(c.H))
+
+	b := c.TopBlock(coords)
+	if b == nil {
+		return false
+	}
+
+	ticker, ok := blockTickers[b.Type]
+	if !ok || ticker.RandomTick == nil {
+		return false
+	}
+
+	return ticker.RandomTick(m, c, coords, b)
+}
+
+// growSapling turns the sapling into a grown tree if nothing is in the way, otherwise it tries again later
+func growSapling(m *Map, c *Chunk, coords IntVec, b *Block) bool {
+	if !m.CanGrowTree(c, coords, b) {
+		c.ScheduleTick(coords, b.Type, SaplingRetryTime)
+		return false
+	}
+
+	stack := c.Blocks[coords.Y][coords.X]
+	tree := NewBlock(BlockTypeTree, BlockTypeTreeFrameGrownTop, b.Position)
+	stack[len(stack)-1] = tree
+
+	AddCollideable(tree)
+
+	return true
+}
+
+// CanGrowTree returns true if a tree can grow in place of sapling b at coords in chunk c. The trees around it have to
+// be far enough away for it not to grow into them and nobody can be standing on it.
+func (m *Map) CanGrowTree(c *Chunk, coords IntVec, b *Block) bool {
+	center := NewIntVec(c.X*c.W+coords.X, c.Y*c.H+coords.Y)
+
+	for y := -1; y <= 1; y++ {
+		for x := -1; x <= 1; x++ {
+			if x == 0 && y == 0 {
+				continue
+			}
+
+			// a neighbour in a chunk that isn't loaded can't be checked so it's in the way until it is
+			n, loaded := m.LoadedTopBlockAt(NewIntVec(center.X+x, center.Y+y))
+			if !loaded || (n != nil && n.IsSolid()) {
+				return false
+			}
+		}
+	}
+
+	for _, o := range Collideables.Query(b.GetPosition(), b.GetSize()) {
+		if o.GetType() == CollideableTypePlayer && CollisionBBox(o.GetPosition(), o.GetSize(), b.GetPosition(), b.GetSize()) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// regrowBerries sometimes grows the berries back on a bush that's been picked
+func regrowBerries(m *Map, c *Chunk, coords IntVec, b *Block) bool {
+	if b.Frame != BlockTypeBushFrameBare || rand.Float64() >= BushRegrowChance {
+		return false
+	}
+
+	b.Frame = BlockTypeBushFrameBerries
+
+	return true
+}
@@ -1,9 +1,6 @@
 package game
 
-import (
-	"github.com/gopxl/pixel/v2"
-	"github.com/gopxl/pixel/v2/backends/opengl"
-)
+import "github.com/gopxl/pixel/v2"
 
 type Camera struct {
 	Matrix    pixel.Matrix
@@ -27,11 +24,11 @@ func (c *Camera) Update(pos pixel.Vec) {
 	c.Position = pos
 }
 
-func (c *Camera) StartCamera(win *opengl.Window) {
+func (c *Camera) StartCamera(win Window) {
 	c.Matrix = pixel.IM.Scaled(c.Position, c.Zoom).Moved(win.Bounds().Center().Sub(c.Position))
 	win.SetMatrix(c.Matrix)
 }
 
-func (c *Camera) EndCamera(win *opengl.Window) {
+func (c *Camera) EndCamera(win Window) {
 	win.SetMatrix(pixel.IM)
 }
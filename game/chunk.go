@@ -2,7 +2,6 @@ package game
 
 import (
 	"github.com/gopxl/pixel/v2"
-	"github.com/gopxl/pixel/v2/backends/opengl"
 	"math/rand/v2"
 )
 
@@ -12,63 +11,188 @@ type Chunk struct {
 	W      int
 	H      int
 	Blocks map[int]map[int][]*Block
+
+	// every block in the chunk that has an entity so they can be ticked without going through every block
+	Entities []*Block
+	Ticks    []*ScheduledTick
+
+	// cached draw data, only rebuilt when Dirty is set
+	FloorBatch      *pixel.Batch
+	TreeBatchBottom *pixel.Batch
+	TreeBatchTop    *pixel.Batch
+	Dirty           bool
+}
+
+// NewChunkRand returns the random source used to generate the chunk at x, y so the same seed always makes the same chunk
+func NewChunkRand(seed uint64, x, y int) *rand.Rand {
+	return rand.New(rand.NewPCG(seed, mixChunkCoords(x, y)))
 }
 
-func NewChunk(win *opengl.Window, x, y, w, h int, chunkType string, g *Game) *Chunk {
+// mixChunkCoords packs chunk coordinates into a single well mixed number (splitmix64) so neighbouring chunks don't get similar streams
+func mixChunkCoords(x, y int) uint64 {
+	z := uint64(uint32(int32(x)))<<32 | uint64(uint32(int32(y)))
+	z += 0x9e3779b97f4a7c15
+	z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+	z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+	return z ^ (z >> 31)
+}
+
+// NewEmptyChunk returns a chunk at x, y with nothing in it
+func NewEmptyChunk(x, y, w, h int) *Chunk {
 	newChunk := &Chunk{
 		X:      x,
 		Y:      y,
 		W:      w,
 		H:      h,
 		Blocks: map[int]map[int][]*Block{},
+		Dirty:  true,
 	}
 
 	for ty := 0; ty < h; ty++ {
 		newChunk.Blocks[ty] = map[int][]*Block{}
+	}
+
+	return newChunk
+}
+
+// TilePosition returns where the tile at tx, ty in the chunk is drawn
+func (c *Chunk) TilePosition(tx, ty int) pixel.Vec {
+	return pixel.V(float64(c.X)*256+float64(tx)*16, float64(c.Y)*256+float64(ty)*16)
+}
+
+// NewChunk generates the chunk at x, y from seed, its ground and everything on it depend on biomeAt, the biome at
+// every map block coordinate
+func NewChunk(x, y, w, h int, seed uint64, biomeAt func(x, y int) *Biome) *Chunk {
+	rnd := NewChunkRand(seed, x, y)
+	newChunk := NewEmptyChunk(x, y, w, h)
+
+	for ty := 0; ty < h; ty++ {
 		for tx := 0; tx < w; tx++ {
-			var newBlock *Block
-
-			pos := pixel.V(float64(x)*256+float64(tx)*16, float64(y)*256+float64(ty)*16)
-
-			if chunkType == "dirt" {
-				newBlock = NewBlock(win, BlockTypeDirt, BlockTypeDirtFrameDirt, pos)
-			} else if chunkType == "grass" {
-				var frame byte
-				rnd := rand.IntN(100)
-
-				if rnd < 80 {
-					frame = BlockTypeGrassFrame1
-				} else if rnd < 95 {
-					frame = BlockTypeGrassFrame2
-				} else if rnd < 99 {
-					frame = BlockTypeGrassFrame3
-				} else if rnd <= 100 {
-					frame = BlockTypeGrassFrame4
-				}
-				newBlock = NewBlock(win, BlockTypeGrass, frame, pos)
-			}
+			pos := newChunk.TilePosition(tx, ty)
+			biome := biomeAt(x*w+tx, y*h+ty)
 
-			newChunk.Blocks[ty][tx] = append(newChunk.Blocks[ty][tx], newBlock)
+			frame := GetBlockDefinition(biome.Ground).RandomFrame(rnd)
+			ground := NewBlock(biome.Ground, frame, pos)
+			newChunk.Blocks[ty][tx] = append(newChunk.Blocks[ty][tx], ground)
+
+			if ground.IsSolid() {
+				AddCollideable(ground)
+			}
 
-			// add trees maybe
-			objRnd := rand.IntN(1000)
+			// maybe add one thing on top of the ground, the chances are stacked so only one roll is needed
+			roll := rnd.Float64()
 
-			if objRnd <= 20 {
+			if roll < biome.TreeChance {
 				spawnSafe := 50.0
 				if (pos.X > spawnSafe || pos.X < -spawnSafe) && (pos.Y > spawnSafe || pos.Y < -spawnSafe) {
-					newTreeBlock := NewBlock(win, BlockTypeTree, BlockTypeTreeFrameGrownTop, pos)
+					newTreeBlock := NewBlock(BlockTypeTree, BlockTypeTreeFrameGrownTop, pos)
 					newChunk.Blocks[ty][tx] = append(newChunk.Blocks[ty][tx], newTreeBlock)
 					AddCollideable(newTreeBlock)
 				}
-			} else if objRnd > 21 && objRnd < 24 {
-				newStoneBlock := NewBlock(win, BlockTypeStone, BlockTypeStoneFrame1, pos)
+				continue
+			}
+			roll -= biome.TreeChance
+
+			if roll < biome.StoneChance {
+				newStoneBlock := NewBlock(BlockTypeStone, BlockTypeStoneFrame1, pos)
 				newChunk.Blocks[ty][tx] = append(newChunk.Blocks[ty][tx], newStoneBlock)
-			} else if objRnd > 24 && objRnd < 28 {
-				newCopperBlock := NewBlock(win, BlockTypeCopper, BlockTypeCopperFrame1, pos)
+				continue
+			}
+			roll -= biome.StoneChance
+
+			if roll < biome.CopperChance {
+				newCopperBlock := NewBlock(BlockTypeCopper, BlockTypeCopperFrame1, pos)
 				newChunk.Blocks[ty][tx] = append(newChunk.Blocks[ty][tx], newCopperBlock)
+				continue
+			}
+			roll -= biome.CopperChance
+
+			if roll < biome.BushChance {
+				newBushBlock := NewBlock(BlockTypeBush, BlockTypeBushFrameBerries, pos)
+				newChunk.Blocks[ty][tx] = append(newChunk.Blocks[ty][tx], newBushBlock)
 			}
 		}
 	}
 
 	return newChunk
 }
+
+// TopBlock returns the block on top of the stack at coords in the chunk, nil if there isn't one
+func (c *Chunk) TopBlock(coords IntVec) *Block {
+	stack := c.Blocks[coords.Y][coords.X]
+	if len(stack) == 0 {
+		return nil
+	}
+
+	return stack[len(stack)-1]
+}
+
+// AddEntityBlock keeps track of b if it has an entity
+func (c *Chunk) AddEntityBlock(b *Block) {
+	if b.Entity != nil {
+		c.Entities = append(c.Entities, b)
+	}
+}
+
+func (c *Chunk) RemoveEntityBlock(b *Block) {
+	for i, e := range c.Entities {
+		if e == b {
+			c.Entities = append(c.Entities[:i], c.Entities[i+1:]...)
+			return
+		}
+	}
+}
+
+// TickEntities ticks every block entity in the chunk that changes over time
+func (c *Chunk) TickEntities(dt float64) {
+	for _, b := range c.Entities {
+		if t, ok := b.Entity.(TickingBlockEntity); ok {
+			t.Tick(dt)
+		}
+	}
+}
+
+// RefreshDrawBatch redraws the chunks blocks into its own batches if anything has changed since the last time
+func (c *Chunk) RefreshDrawBatch(pic pixel.Picture) {
+	if c.FloorBatch == nil {
+		c.FloorBatch = pixel.NewBatch(&pixel.TrianglesData{}, pic)
+		c.TreeBatchBottom = pixel.NewBatch(&pixel.TrianglesData{}, pic)
+		c.TreeBatchTop = pixel.NewBatch(&pixel.TrianglesData{}, pic)
+		c.Dirty = true
+	}
+
+	if !c.Dirty {
+		return
+	}
+
+	c.FloorBatch.Clear()
+	c.TreeBatchBottom.Clear()
+	c.TreeBatchTop.Clear()
+
+	treeTops := []*Block{} // so we can redraw in reverse later because drawing from top to bottom causes overlapping issue
+
+	for ty := 0; ty < c.H; ty++ {
+		for tx := 0; tx < c.W; tx++ {
+			for _, tile := range c.Blocks[ty][tx] {
+				def := GetBlockDefinition(tile.Type)
+				if def == nil {
+					continue
+				}
+
+				if def.Layer == DrawLayerTree {
+					treeTops = append(treeTops, tile)
+					def.Sprite(def.BottomFrame).Draw(c.TreeBatchBottom, pixel.IM.Moved(tile.GetPosition()))
+				} else {
+					def.Sprite(tile.Frame).Draw(c.FloorBatch, pixel.IM.Moved(tile.GetPosition()))
+				}
+			}
+		}
+	}
+
+	for i := len(treeTops) - 1; i >= 0; i-- {
+		def := GetBlockDefinition(treeTops[i].Type)
+		def.Sprite(def.TopFrame).Draw(c.TreeBatchTop, pixel.IM.Moved(treeTops[i].GetPosition()))
+	}
+
+	c.Dirty = false
+}
@@ -1,9 +1,6 @@
 package game
 
-import (
-	"github.com/gopxl/pixel/v2"
-	"github.com/gopxl/pixel/v2/backends/opengl"
-)
+import "github.com/gopxl/pixel/v2"
 
 const (
 	CollideableTypeBlock   byte = 0
@@ -17,6 +14,6 @@ type Collideable interface {
 	Collide(Collideable)
 	IsSolid() bool
 	GetType() byte
-	DrawDebug(*opengl.Window)
+	DrawDebug(pixel.Target)
 	GetOldPosition() pixel.Vec
 }
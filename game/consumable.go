@@ -0,0 +1,50 @@
+package game
+
+import "github.com/gopxl/pixel/v2"
+
+const (
+	ConsumableTypeBerries     byte = 0
+	ConsumableTypeWaterBucket byte = 1
+)
+
+// Consumable is an item that is used up to restore some of the players stats
+type Consumable struct {
+	Name     string
+	Frame    [4]float64 // x, y, w, h in the tile sheet measured from the top left
+	Health   float64
+	Hunger   float64
+	Thirst   float64
+	MaxStack int      // how many fit in one inventory slot, 0 for the default
+	Returns  *ItemKey // what's given back once it's used up, like the bucket water was in
+	Sprite   *pixel.Sprite
+}
+
+var Consumables = map[byte]*Consumable{
+	ConsumableTypeBerries: {
+		Name:   "berries",
+		Frame:  [4]float64{32, 176, 16, 16},
+		Hunger: 15,
+		Thirst: 5,
+	},
+	ConsumableTypeWaterBucket: {
+		Name:     "water bucket",
+		Frame:    [4]float64{112, 176, 16, 16},
+		Thirst:   40,
+		MaxStack: 1,
+		Returns:  &ItemKey{UnderlyingTypeMaterial, MaterialTypeBucket, 0},
+	},
+}
+
+// LoadConsumableSprites cuts the sprite of every consumable out of the tile sheet
+func LoadConsumableSprites(s *Spritesheet) {
+	h := s.Picture.Bounds().H()
+
+	for _, c := range Consumables {
+		f := c.Frame
+		c.Sprite = pixel.NewSprite(s.Picture, pixel.R(f[0], h-f[1], f[0]+f[2], h-f[1]-f[3]))
+	}
+}
+
+func GetConsumable(itemType byte) *Consumable {
+	return Consumables[itemType]
+}
@@ -2,7 +2,6 @@ package game
 
 import (
 	"github.com/gopxl/pixel/v2"
-	"github.com/gopxl/pixel/v2/backends/opengl"
 	"image"
 	"image/color"
 )
@@ -43,7 +42,7 @@ func MakeRect(w, h int, c color.Color) (*image.RGBA, *pixel.Sprite) {
 }
 
 // Rect draws a rectangle utilizing HLine() and VLine()
-func MakeDebugRect(win *opengl.Window, w, h int) *pixel.Sprite {
+func MakeDebugRect(w, h int) *pixel.Sprite {
 	_, yExists := RectangleSprites[h]
 	if yExists {
 		_, xExists := RectangleSprites[h][w]
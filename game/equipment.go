@@ -0,0 +1,98 @@
+package game
+
+import "github.com/gopxl/pixel/v2"
+
+// equipment slots, the order they're shown in from the top of the inventory panel
+const (
+	EquipmentSlotHead  byte = 0
+	EquipmentSlotTorso byte = 1
+	EquipmentSlotLegs  byte = 2
+	EquipmentSlotFeet  byte = 3
+
+	EquipmentSlotCount = 4
+
+	// most damage armor can take away
+	MaxArmor = 0.8
+)
+
+const (
+	EquipmentTypeCopperHelmet     byte = 0
+	EquipmentTypeCopperChestplate byte = 1
+	EquipmentTypeCopperLeggings   byte = 2
+	EquipmentTypeCopperBoots      byte = 3
+	EquipmentTypeGrassSandals     byte = 4
+)
+
+// Equipment is an item that can be worn in one of the equipment slots
+type Equipment struct {
+	Name   string
+	Slot   byte
+	Frame  [4]float64 // x, y, w, h in the tile sheet measured from the top left
+	Armor  float64    // fraction of damage taken away
+	Speed  float64    // fraction added to movement speed
+	Sprite *pixel.Sprite
+}
+
+var Equipments = map[byte]*Equipment{
+	EquipmentTypeCopperHelmet: {
+		Name:  "copper helmet",
+		Slot:  EquipmentSlotHead,
+		Frame: [4]float64{0, 240, 16, 16},
+		Armor: 0.1,
+	},
+	EquipmentTypeCopperChestplate: {
+		Name:  "copper chestplate",
+		Slot:  EquipmentSlotTorso,
+		Frame: [4]float64{16, 240, 16, 16},
+		Armor: 0.25,
+		Speed: -0.05,
+	},
+	EquipmentTypeCopperLeggings: {
+		Name:  "copper leggings",
+		Slot:  EquipmentSlotLegs,
+		Frame: [4]float64{32, 240, 16, 16},
+		Armor: 0.15,
+		Speed: -0.05,
+	},
+	EquipmentTypeCopperBoots: {
+		Name:  "copper boots",
+		Slot:  EquipmentSlotFeet,
+		Frame: [4]float64{48, 240, 16, 16},
+		Armor: 0.1,
+	},
+	EquipmentTypeGrassSandals: {
+		Name:  "grass sandals",
+		Slot:  EquipmentSlotFeet,
+		Frame: [4]float64{64, 240, 16, 16},
+		Speed: 0.15,
+	},
+}
+
+// LoadEquipmentSprites cuts the sprite of every piece of equipment out of the tile sheet
+func LoadEquipmentSprites(s *Spritesheet) {
+	h := s.Picture.Bounds().H()
+
+	for _, e := range Equipments {
+		f := e.Frame
+		e.Sprite = pixel.NewSprite(s.Picture, pixel.R(f[0], h-f[1], f[0]+f[2], h-f[1]-f[3]))
+	}
+}
+
+// EquipmentSlotPosition returns where slot is in the inventory panel, in the same coordinates as the crafting grid
+func EquipmentSlotPosition(slot byte) pixel.Vec {
+	return pixel.V(-3, 2-float64(slot))
+}
+
+func GetEquipment(itemType byte) *Equipment {
+	return Equipments[itemType]
+}
+
+// CanEquip returns true if item can be worn in slot
+func CanEquip(item *InventoryItem, slot byte) bool {
+	if item == nil || item.UnderlyingType != UnderlyingTypeEquipment {
+		return false
+	}
+
+	e := GetEquipment(item.ItemType)
+	return e != nil && e.Slot == slot
+}
@@ -2,7 +2,6 @@ package game
 
 import (
 	"github.com/gopxl/pixel/v2"
-	"github.com/gopxl/pixel/v2/backends/opengl"
 	"math"
 )
 
@@ -18,6 +17,8 @@ type Floater struct {
 	UnderlyingType byte
 	ItemType       byte
 	Frame          byte
+	Amount         int // how many of the item are picked up with it
+	Meta           ItemMeta
 	Size           pixel.Vec
 	Scale          float64
 	Solid          bool
@@ -32,7 +33,7 @@ type Floater struct {
 	Deleted        bool
 }
 
-func NewFloater(win *opengl.Window, underType, itemType, frame byte, position, velocity pixel.Vec) *Floater {
+func NewFloater(underType, itemType, frame byte, position, velocity pixel.Vec) *Floater {
 	f := &Floater{
 		Position:       position,
 		Velocity:       velocity,
@@ -40,6 +41,8 @@ func NewFloater(win *opengl.Window, underType, itemType, frame byte, position, v
 		UnderlyingType: underType,
 		ItemType:       itemType,
 		Frame:          frame,
+		Amount:         1,
+		Meta:           NewItemMeta(underType, itemType),
 		Size:           pixel.V(8, 8),
 		Scale:          0.5,
 		ScaleSpeed:     0.25,
@@ -49,18 +52,27 @@ func NewFloater(win *opengl.Window, underType, itemType, frame byte, position, v
 		Solid:          false,
 		RotationSpeed:  3,
 		Rotation:       0,
-		DebugRect:      MakeDebugRect(win, 8, 8),
+		DebugRect:      MakeDebugRect(8, 8),
 	}
 
-	if itemType == BlockTypeDirt {
-		f.Sprite = Tiles[BlockTypeDirt][BlockTypeDirtFrameDirt]
-	} else if itemType == BlockTypeGrass {
-		f.Sprite = Tiles[BlockTypeGrass][BlockTypeGrassFrame1]
-	}
+	f.Sprite = ItemSprite(underType, itemType, frame)
+
+	return f
+}
+
+// SpawnFloater creates a floater and adds it to the world
+func SpawnFloater(underType, itemType, frame byte, position, velocity pixel.Vec) *Floater {
+	f := NewFloater(underType, itemType, frame, position, velocity)
+	Floaters = append(Floaters, f)
+	AddCollideable(f)
 
 	return f
 }
 
+func (f *Floater) Key() ItemKey {
+	return ItemKey{UnderlyingType: f.UnderlyingType, ItemType: f.ItemType, Frame: f.Frame}
+}
+
 func (f *Floater) GetPosition() pixel.Vec {
 	return f.Position
 }
@@ -86,6 +98,8 @@ func (f *Floater) GetOldPosition() pixel.Vec {
 }
 
 func (f *Floater) Update(dt float64) {
+	f.OldPosition = f.Position
+
 	f.Rotation -= dt * f.RotationSpeed
 	if f.Rotation <= -math.Pi*(2) {
 		f.Rotation = 0
@@ -140,12 +154,17 @@ func (f *Floater) Update(dt float64) {
 	}
 }
 
-func (f *Floater) Draw(win *opengl.Window) {
-	pos := pixel.IM.Moved(pixel.ZV).Scaled(pixel.ZV, f.Scale).Rotated(pixel.ZV, f.Rotation).Moved(f.Position)
-	FloaterBorderSprite.Draw(win, pos)
-	f.Sprite.Draw(win, pos)
+// InterpolatedPosition returns the position alpha of the way from the last tick to the current one
+func (f *Floater) InterpolatedPosition(alpha float64) pixel.Vec {
+	return pixel.Lerp(f.OldPosition, f.Position, alpha)
+}
+
+func (f *Floater) Draw(t pixel.Target, alpha float64) {
+	pos := pixel.IM.Moved(pixel.ZV).Scaled(pixel.ZV, f.Scale).Rotated(pixel.ZV, f.Rotation).Moved(f.InterpolatedPosition(alpha))
+	FloaterBorderSprite.Draw(t, pos)
+	f.Sprite.Draw(t, pos)
 }
 
-func (f *Floater) DrawDebug(win *opengl.Window) {
-	f.DebugRect.Draw(win, pixel.IM.Moved(pixel.ZV).Scaled(pixel.ZV, 0.5).Moved(f.Position))
+func (f *Floater) DrawDebug(t pixel.Target) {
+	f.DebugRect.Draw(t, pixel.IM.Moved(pixel.ZV).Scaled(pixel.ZV, 0.5).Moved(f.Position))
 }
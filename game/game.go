@@ -2,37 +2,54 @@ package game
 
 import (
 	"github.com/gopxl/pixel/v2"
-	"github.com/gopxl/pixel/v2/backends/opengl"
 	"github.com/gopxl/pixel/v2/ext/text"
 	"golang.org/x/image/colornames"
 	"golang.org/x/image/font"
 	"image"
 	"math"
+	"path/filepath"
+)
+
+const (
+	CollisionCellSize = 64.0
+
+	TicksPerSecond  = 60
+	TickDuration    = 1.0 / TicksPerSecond // seconds simulated by every tick
+	MaxCatchUpTicks = 5                    // most ticks run in one frame after a stall, the rest of the time is dropped
 )
 
 var (
+	// where every asset is loaded from, it's relative to the working directory so tests point it at the repos assets
+	AssetsDirectory = "./assets"
+
 	Font  font.Face
 	Atlas *text.Atlas
 
-	Tiles        map[byte]map[byte]*pixel.Sprite
 	Floaters     []*Floater
-	Collideables []Collideable // list of objects to check for collision
+	Collideables = NewSpatialHash(CollisionCellSize) // objects to check for collision
 
 	FloaterBorderImage  *image.RGBA
 	FloaterBorderSprite *pixel.Sprite
+	WhitePixelSprite    *pixel.Sprite // a white pixel stretched and tinted into bars like tool durability
 )
 
 type Game struct {
-	Map                   *Map
-	Player                *Player
+	*World
 	CollideablesDrawDebug bool
 	GUI                   *GUI
-	Window                *opengl.Window
+	Window                Window
 	Camera                *Camera
+	Alpha                 float64 // how far the frame being drawn is between the last tick and the next one, from 0 to 1
 }
 
-func NewGame(name string, win *opengl.Window) (*Game, error) {
-	face, err := loadTTF("./assets/font/munro.ttf", 24)
+// AssetPath returns the path of the asset called name in AssetsDirectory
+func AssetPath(name string) string {
+	return filepath.Join(AssetsDirectory, name)
+}
+
+// LoadAssets loads the font, block and structure definitions shared by everything in the game, none of it needs a window
+func LoadAssets() (*Spritesheet, error) {
+	face, err := loadTTF(AssetPath("font/munro.ttf"), 24)
 	if err != nil {
 		return nil, err
 	}
@@ -42,40 +59,40 @@ func NewGame(name string, win *opengl.Window) (*Game, error) {
 	Font = face
 	Atlas = atlas
 
-	s, err := NewSpritesheet("./assets/tiles/all.png")
+	s, err := NewSpritesheet(AssetPath("tiles/all.png"))
 	if err != nil {
 		return nil, err
 	}
 
-	Tiles = map[byte]map[byte]*pixel.Sprite{
-		BlockTypeDirt: {
-			BlockTypeDirtFrameDirt: pixel.NewSprite(s.Picture, pixel.R(0, s.Picture.Bounds().H(), 16, s.Picture.Bounds().H()-16)),
-		},
-		BlockTypeGrass: {
-			BlockTypeGrassFrame1: pixel.NewSprite(s.Picture, pixel.R(16, s.Picture.Bounds().H(), 16*2, s.Picture.Bounds().H()-16)),
-			BlockTypeGrassFrame2: pixel.NewSprite(s.Picture, pixel.R(2*16, s.Picture.Bounds().H(), 3*16, s.Picture.Bounds().H()-16)),
-			BlockTypeGrassFrame3: pixel.NewSprite(s.Picture, pixel.R(3*16, s.Picture.Bounds().H(), 4*16, s.Picture.Bounds().H()-16)),
-			BlockTypeGrassFrame4: pixel.NewSprite(s.Picture, pixel.R(4*16, s.Picture.Bounds().H(), 5*16, s.Picture.Bounds().H()-16)),
-		},
-		BlockTypeTree: {
-			BlockTypeTreeFrameSapling:     pixel.NewSprite(s.Picture, pixel.R(0, s.Picture.Bounds().H()-4*16, 16, s.Picture.Bounds().H()-5*16)),
-			BlockTypeTreeFrameGrownTop:    pixel.NewSprite(s.Picture, pixel.R(16, s.Picture.Bounds().H()-4*16, 3*16, s.Picture.Bounds().H()-6*16)),
-			BlockTypeTreeFrameGrownBottom: pixel.NewSprite(s.Picture, pixel.R(3*16, s.Picture.Bounds().H()-4*16, 5*16, s.Picture.Bounds().H()-6*16)),
-		},
-		BlockTypeStone: {
-			BlockTypeStoneFrame1: pixel.NewSprite(s.Picture, pixel.R(0, s.Picture.Bounds().H()-2*16, 16, s.Picture.Bounds().H()-3*16)),
-		},
-		BlockTypeCopper: {
-			BlockTypeCopperFrame1: pixel.NewSprite(s.Picture, pixel.R(0, s.Picture.Bounds().H()-3*16, 16, s.Picture.Bounds().H()-4*16)),
-		},
-	}
-
-	p, err := NewPlayer(win)
+	if err := LoadBlockDefinitions(AssetPath(BlockDefinitionsPath), s); err != nil {
+		return nil, err
+	}
+
+	LoadConsumableSprites(s)
+	LoadEquipmentSprites(s)
+	LoadMaterialSprites(s)
+
+	if err := LoadToolSprites(AssetPath(ToolsPath)); err != nil {
+		return nil, err
+	}
+
+	LoadItemDefinitions()
+
+	if err := LoadStructureDefinitions(AssetPath(StructureDefinitionsPath)); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// NewGame opens the world called name, generator is only used if the world has to be created
+func NewGame(name, generator string, win Window) (*Game, error) {
+	s, err := LoadAssets()
 	if err != nil {
 		return nil, err
 	}
 
-	m, err := NewMap(name, s)
+	w, err := OpenWorld(name, generator, s)
 	if err != nil {
 		return nil, err
 	}
@@ -88,8 +105,7 @@ func NewGame(name string, win *opengl.Window) (*Game, error) {
 	cam := NewCamera()
 
 	g := &Game{
-		Map:    m,
-		Player: p,
+		World:  w,
 		GUI:    gui,
 		Window: win,
 		Camera: cam,
@@ -98,64 +114,48 @@ func NewGame(name string, win *opengl.Window) (*Game, error) {
 	return g, nil
 }
 
-func (g *Game) Init(win *opengl.Window) {
-	AddCollideable(g.Player)
-
+func (g *Game) Init() {
 	FloaterBorderImage, FloaterBorderSprite = MakeRect(18, 18, colornames.Black)
-
-	// add an example floater at 50, 50
-	f := NewFloater(win, UnderlyingTypePlaceableBlock, BlockTypeDirt, BlockTypeDirtFrameDirt, pixel.V(50, 50), pixel.V(0, 0))
-	Floaters = append(Floaters, f)
-	AddCollideable(f)
+	_, WhitePixelSprite = MakeRect(1, 1, colornames.White)
 }
 
-func (g *Game) Update(win *opengl.Window, dt float64) {
-	newFloaters := []*Floater{}
-	// cleanup deleted floaters
-	for _, f := range Floaters {
-		if !f.Deleted {
-			newFloaters = append(newFloaters, f)
-		} else {
-			for x, c := range Collideables {
-				if f == c {
-					Collideables = append(Collideables[:x], Collideables[x+1:]...)
-				}
-			}
-		}
-	}
-	Floaters = newFloaters
+func (g *Game) Update(input Input, dt float64) {
+	wasDead := g.Player.Dead
 
-	g.Map.ChunkPosition = g.Player.GetChunkPosition()
-	g.Map.GenerateChunksAroundPlayer(g, win)
+	g.World.Tick(input, dt)
 
-	for _, f := range Floaters {
-		f.Update(dt)
+	// whatever was in the crafting grid or being held is dropped along with the inventory
+	if g.Player.Dead && !wasDead {
+		g.GUI.DropItems(g.Player.Position)
+		g.CloseInventory()
 	}
 
-	g.Player.Update(win, dt)
-	g.GUI.Update(dt)
-	g.Camera.Update(g.Player.Position)
+	// an open container closes once the player walks away from it
+	if b := g.GUI.ContainerBlock; b != nil && !g.Player.CanReach(b.Position.Scaled(1.0/16)) {
+		g.CloseInventory()
+	}
 
-	g.CheckCollisions()
+	g.GUI.Update(dt)
 }
 
-func (g *Game) Draw() {
+// Draw draws the game alpha of the way between the previous tick and the current one so movement looks smooth no
+// matter how the frame rate lines up with the tick rate
+func (g *Game) Draw(alpha float64) {
+	g.Alpha = alpha
+
+	g.Camera.Update(g.Player.InterpolatedPosition(alpha))
 	g.Camera.StartCamera(g.Window)
 
 	g.Player.GetMouseMapBlockPosition(g)
 
 	// draw map
-	g.Map.FloorBatch.Clear()
-	g.Map.TreeBatchBottom.Clear()
-	g.Map.TreeBatchTop.Clear()
-
 	g.Map.RefreshDrawBatch()
 	g.Map.FloorBatch.Draw(g.Window)
 	g.Map.TreeBatchBottom.Draw(g.Window)
 
 	// draw floaters
 	for _, f := range Floaters {
-		f.Draw(g.Window)
+		f.Draw(g.Window, alpha)
 	}
 
 	// draw player
@@ -165,23 +165,32 @@ func (g *Game) Draw() {
 
 	// debug
 	if g.CollideablesDrawDebug {
-		for i := 0; i < len(Collideables); i++ {
-			Collideables[i].DrawDebug(g.Window)
-		}
+		Collideables.ForEach(func(c Collideable) {
+			c.DrawDebug(g.Window)
+		})
 	}
 
 	g.Camera.EndCamera(g.Window)
 
 	g.GUI.SetInventoryItems(g.Player.Inventory)
+	g.GUI.SetEquipment(g.Player.Equipment)
 	g.GUI.Draw(g.Camera)
+
+	if g.Player.Dead {
+		g.GUI.DrawDeathScreen(g.Camera)
+	}
 }
 
 func (g *Game) ButtonCallback(btn pixel.Button, action pixel.Action) {
+	if g.Player.Dead {
+		return
+	}
+
 	g.Player.ButtonCallback(g, btn, action)
 	g.GUI.ButtonCallback(btn, action)
 }
 
-func (g *Game) Scroll(win *opengl.Window, scroll pixel.Vec) {
+func (g *Game) Scroll(win Window, scroll pixel.Vec) {
 	if scroll.Y == 1 {
 		if g.Camera.Zoom < 42 {
 			g.Camera.Zoom *= math.Pow(g.Camera.ZoomSpeed, scroll.Y)
@@ -194,49 +203,62 @@ func (g *Game) Scroll(win *opengl.Window, scroll pixel.Vec) {
 }
 
 func (g *Game) CharCallback(r rune) {
+	if g.Player.Dead {
+		if r == 'r' {
+			g.RespawnPlayer()
+		}
+		return
+	}
+
 	if r == ']' {
 		g.CollideablesDrawDebug = !g.CollideablesDrawDebug
 	} else if r == 'i' {
-		g.GUI.ShouldDrawInventory = !g.GUI.ShouldDrawInventory
+		if g.GUI.ShouldDrawInventory {
+			g.CloseInventory()
+		} else {
+			g.GUI.ShouldDrawInventory = true
+			g.Player.InInventory = true
+		}
+	}
 
-		if g.GUI.HoldingInvItem != nil {
-			g.GUI.HoldingInvItem.ShouldUseDrawPosition = false
-			g.GUI.HoldingInvItem.Count.Orig = g.GUI.HoldingInvItem.GetDrawPosition(g.GUI.Window)
-			i := g.GUI.HoldingInvItem
+	g.Player.CharCallback(g, r)
+}
 
-			g.GUI.Inventory[int(i.InventoryPosition.Y)][int(i.InventoryPosition.X)] = g.GUI.HoldingInvItem
+// CloseInventory closes the inventory and any open container, putting back whatever was being held
+func (g *Game) CloseInventory() {
+	g.GUI.ReturnHeldItem()
+	g.GUI.CloseContainer()
+	g.GUI.ShouldDrawInventory = false
+	g.Player.InInventory = false
+}
 
-			g.GUI.HoldingInvItem = nil
+// UpdateFloaters removes floaters that have been picked up and moves the rest
+func UpdateFloaters(dt float64) {
+	newFloaters := []*Floater{}
+	// cleanup deleted floaters
+	for _, f := range Floaters {
+		if !f.Deleted {
+			newFloaters = append(newFloaters, f)
+		} else {
+			RemoveCollideable(f)
 		}
-
-		g.Player.InInventory = g.GUI.ShouldDrawInventory
 	}
+	Floaters = newFloaters
 
-	g.Player.CharCallback(g, r)
+	for _, f := range Floaters {
+		f.Update(dt)
+	}
 }
 
 func AddCollideable(c Collideable) {
-	Collideables = append(Collideables, c)
+	Collideables.Insert(c)
 }
 
-func (g *Game) CheckCollisions() {
-	for i := 0; i < len(Collideables); i++ {
-		for x := 0; x < len(Collideables); x++ {
-			if x == i {
-				continue
-			}
-
-			first := Collideables[i]
-			second := Collideables[x]
-
-			if !first.IsSolid() || !second.IsSolid() {
-				continue
-			}
+func RemoveCollideable(c Collideable) {
+	Collideables.Remove(c)
+}
 
-			if CollisionBBox(first.GetPosition(), first.GetSize(), second.GetPosition(), second.GetSize()) {
-				first.Collide(second)
-				second.Collide(first)
-			}
-		}
-	}
+// CheckCollisions tests every solid collideable against the ones near it
+func CheckCollisions() {
+	Collideables.CheckCollisions()
 }
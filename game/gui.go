@@ -2,15 +2,16 @@ package game
 
 import (
 	"github.com/gopxl/pixel/v2"
-	"github.com/gopxl/pixel/v2/backends/opengl"
+	"github.com/gopxl/pixel/v2/ext/text"
 	"golang.org/x/image/colornames"
 	"image"
+	"image/color"
 	"math"
 	"strconv"
 )
 
 type GUI struct {
-	Window      *opengl.Window
+	Window      Window
 	Camera      *Camera
 	Spritesheet *Spritesheet
 	BarSprite   *pixel.Sprite
@@ -45,17 +46,24 @@ type GUI struct {
 	HotbarX               int
 	HotbarSelectionSprite *pixel.Sprite
 	Inventory             [][]*InventoryItem
+	Equipment             []*InventoryItem
 	ShouldDrawInventory   bool
 
-	Tiles map[byte]map[byte]*pixel.Sprite
-
 	HoldingInvItem *InventoryItem
 
-	CraftingSlots [][]*InventoryItem
+	Container      Container // the open container, nil if none is open
+	ContainerBlock *Block    // the block the open container belongs to
+
+	CraftingSlots  [][]*InventoryItem
+	CraftingOutput *InventoryItem // what the crafting grid makes right now, nil if it doesn't match a recipe
+	CraftingRecipe *Recipe
+
+	DeathOverlaySprite *pixel.Sprite
+	DeathText          *text.Text
 }
 
-func NewGUI(win *opengl.Window) (*GUI, error) {
-	s, err := NewSpritesheet("./assets/gui.png")
+func NewGUI(win Window) (*GUI, error) {
+	s, err := NewSpritesheet(AssetPath("gui.png"))
 	if err != nil {
 		return nil, err
 	}
@@ -107,6 +115,13 @@ func NewGUI(win *opengl.Window) (*GUI, error) {
 
 	g.ClearCraftingItems()
 
+	_, g.DeathOverlaySprite = MakeRect(1, 1, color.NRGBA{R: 40, G: 0, B: 0, A: 180})
+
+	g.DeathText = text.New(pixel.ZV, Atlas)
+	g.DeathText.Color = colornames.White
+	g.DeathText.WriteString("You died\n")
+	g.DeathText.WriteString("Press R to respawn")
+
 	return g, nil
 }
 
@@ -127,20 +142,62 @@ func (g *GUI) Draw(cam *Camera) {
 	cam.StartCamera(g.Window)
 }
 
+// DrawDeathScreen darkens the whole window and tells the player how to respawn
+func (g *GUI) DrawDeathScreen(cam *Camera) {
+	cam.EndCamera(g.Window)
+
+	bounds := g.Window.Bounds()
+	g.DeathOverlaySprite.Draw(g.Window, pixel.IM.ScaledXY(pixel.ZV, pixel.V(bounds.W(), bounds.H())).Moved(bounds.Center()))
+
+	textBounds := g.DeathText.Bounds()
+	g.DeathText.Draw(g.Window, pixel.IM.Moved(bounds.Center().Sub(textBounds.Center())))
+
+	cam.StartCamera(g.Window)
+}
+
+// DropItems throws the held item and everything in the crafting grid on the ground at pos
+func (g *GUI) DropItems(pos pixel.Vec) {
+	items := []*InventoryItem{g.HoldingInvItem}
+	for y := 0; y < len(g.CraftingSlots); y++ {
+		items = append(items, g.CraftingSlots[y]...)
+	}
+
+	for _, i := range items {
+		if i == nil || i.Amount <= 0 {
+			continue
+		}
+
+		f := SpawnFloater(i.UnderlyingType, i.ItemType, i.Frame, pos, pixel.ZV)
+		f.Amount = i.Amount
+		f.Meta = i.Meta
+	}
+
+	g.HoldingInvItem = nil
+	g.ClearCraftingItems()
+	g.UpdateCraftingOutput()
+}
+
 func (g *GUI) RedrawBars() {
 	// health
-	g.UpdateHealth(50)
+	g.UpdateHealth(g.Health)
 	g.BarSprite.Draw(g.Window, pixel.IM.Moved(pixel.ZV).Scaled(pixel.ZV, g.Scale).Moved(g.HealthBarPosition.Add(pixel.V(g.OffsetX, -g.OffsetY))))
 
 	// hunger
-	g.UpdateHunger(50)
+	g.UpdateHunger(g.Hunger)
 	g.BarSprite.Draw(g.Window, pixel.IM.Moved(pixel.ZV).Scaled(pixel.ZV, g.Scale).Moved(g.HungerBarPosition.Add(pixel.V(g.OffsetX, -g.OffsetY))))
 
 	// thirst
-	g.UpdateThirst(50)
+	g.UpdateThirst(g.Thirst)
 	g.BarSprite.Draw(g.Window, pixel.IM.Moved(pixel.ZV).Scaled(pixel.ZV, g.Scale).Moved(g.ThirstBarPosition.Add(pixel.V(g.OffsetX, -g.OffsetY))))
 }
 
+// SetStats sets the values shown by the health, hunger and thirst bars, all of them go from 0 to 100
+func (g *GUI) SetStats(health, hunger, thirst float64) {
+	g.Health = health
+	g.Hunger = hunger
+	g.Thirst = thirst
+}
+
 func (g *GUI) Update(dt float64) {
 	if g.HoldingInvItem != nil {
 		g.HoldingInvItem.DrawPosition = g.Window.MousePosition()
@@ -251,6 +308,12 @@ func (g *GUI) DrawInventory() {
 		}
 	}
 
+	// an open container takes the place of crafting and equipment
+	if g.Container != nil {
+		g.DrawContainer()
+		return
+	}
+
 	// draw big sprite
 	g.BigSprite.Draw(g.Window, pixel.IM.Moved(pixel.ZV).Scaled(pixel.ZV, g.Scale).Moved(g.BigOffset))
 
@@ -264,12 +327,78 @@ func (g *GUI) DrawInventory() {
 			}
 		}
 	}
+
+	if g.CraftingOutput != nil {
+		g.CraftingOutput.DrawCraftingItem(g.Window, g.Scale)
+	}
+
+	// draw equipment
+	for _, item := range g.Equipment {
+		if item != nil {
+			item.Count.Orig = item.GetCraftingPosition(g.Window, g.Scale)
+			item.DrawCraftingItem(g.Window, g.Scale)
+		}
+	}
+}
+
+// DrawContainer draws the open containers slots in rows above the players inventory
+func (g *GUI) DrawContainer() {
+	offsetX := g.Window.Bounds().W()/2 - (8 * 16) - 16*g.Scale
+	slots := g.Container.Slots()
+
+	for y := 0; y < len(slots); y++ {
+		posY := float64((y+ContainerRowOffset)*16) * g.Scale
+
+		for x := 0; x < len(slots[y]); x++ {
+			if !g.Container.HasSlot(x, y) {
+				continue
+			}
+
+			g.ItemSprite.Draw(g.Window, pixel.IM.Moved(pixel.ZV).Scaled(pixel.ZV, g.Scale).Moved(pixel.V(offsetX+float64(x)*16*g.Scale, 40+posY)))
+
+			if item := slots[y][x]; item != nil {
+				item.Draw(g.Window)
+			}
+		}
+	}
+
+	if f, ok := g.Container.(*Furnace); ok {
+		g.DrawFurnaceProgress(f)
+	}
+}
+
+// DrawFurnaceProgress draws how much of the burning fuel is left next to the fuel slot and how far along smelting is
+// between the input and output slots
+func (g *GUI) DrawFurnaceProgress(f *Furnace) {
+	burnPos := GetInventoryItemDrawPosition(g.Window, FurnaceFuelX+1, FurnaceFuelY+ContainerRowOffset)
+	DrawBar(g.Window, burnPos, 40, 8, f.BurnFraction(), colornames.Orange)
+
+	smeltPos := GetInventoryItemDrawPosition(g.Window, FurnaceInputX+1, FurnaceInputY+ContainerRowOffset)
+	DrawBar(g.Window, smeltPos, 40, 8, f.SmeltFraction(), colornames.White)
+}
+
+// OpenContainer shows the container in block next to the players inventory
+func (g *GUI) OpenContainer(block *Block, c Container) {
+	g.ReturnHeldItem()
+
+	g.Container = c
+	g.ContainerBlock = block
+	g.ShouldDrawInventory = true
+}
+
+func (g *GUI) CloseContainer() {
+	g.Container = nil
+	g.ContainerBlock = nil
 }
 
 func (g *GUI) SetInventoryItems(items [][]*InventoryItem) {
 	g.Inventory = items
 }
 
+func (g *GUI) SetEquipment(items []*InventoryItem) {
+	g.Equipment = items
+}
+
 func (g *GUI) ButtonCallback(btn pixel.Button, action pixel.Action) {
 	mousePos := g.Window.MousePosition()
 	offsetX := g.Window.Bounds().W()/2 - (8 * 16) - 8*g.Scale
@@ -284,47 +413,88 @@ func (g *GUI) ButtonCallback(btn pixel.Button, action pixel.Action) {
 	craftingClickedX := int(math.Floor((mousePos.X-craftingOffsetX)/(16*g.Scale))+1) - 4
 	craftingClickedY := int(math.Floor((mousePos.Y-craftingOffsetY)/(16*g.Scale))) - 5
 
+	clickedContainerY := clickedY - ContainerRowOffset
+	inContainer := g.Container != nil && g.Container.HasSlot(clickedX, clickedContainerY)
+
 	if btn == pixel.MouseButtonLeft && action == pixel.Press {
-		if g.ShouldDrawInventory {
+		if g.ShouldDrawInventory && g.Container != nil {
+			quick := g.Window.Pressed(pixel.KeyLeftShift) || g.Window.Pressed(pixel.KeyRightShift)
+
+			if clickedX >= 0 && clickedX < 8 && clickedY >= 0 && clickedY < 3 {
+				if quick {
+					g.QuickMoveToContainer(clickedX, clickedY)
+				} else {
+					g.HandleInventoryLeftClick(clickedX, clickedY)
+				}
+			} else if inContainer {
+				if quick {
+					g.QuickMoveToInventory(clickedX, clickedContainerY)
+				} else {
+					g.HandleContainerLeftClick(clickedX, clickedContainerY)
+				}
+			}
+		} else if g.ShouldDrawInventory {
 			if clickedX >= 0 && clickedX < 8 && clickedY >= 0 && clickedY < 3 {
 				g.HandleInventoryLeftClick(clickedX, clickedY)
 			}
 
 			g.HandleCraftingSlotLeftClick(craftingClickedX, craftingClickedY)
+			g.HandleCraftingOutputLeftClick(craftingClickedX, craftingClickedY)
 			g.HandleDeleteItemLeftClick(craftingClickedX, craftingClickedY)
+			g.HandleEquipmentLeftClick(craftingClickedX, craftingClickedY)
+			g.UpdateCraftingOutput()
 		}
 	} else if btn == pixel.MouseButtonRight && action == pixel.Press {
-		if g.ShouldDrawInventory {
+		if g.ShouldDrawInventory && g.Container != nil {
+			if clickedX >= 0 && clickedX < 8 && clickedY >= 0 && clickedY < 3 {
+				g.HandleInventoryRightClick(clickedX, clickedY)
+			} else if inContainer {
+				g.HandleContainerRightClick(clickedX, clickedContainerY)
+			}
+		} else if g.ShouldDrawInventory {
 			if clickedX >= 0 && clickedX < 8 && clickedY >= 0 && clickedY < 3 {
 				g.HandleInventoryRightClick(clickedX, clickedY)
 			}
 
 			g.HandleCraftingSlotRightClick(craftingClickedX, craftingClickedY)
+			g.UpdateCraftingOutput()
 		}
 	}
 }
 
 func (g *GUI) HandleInventoryLeftClick(x, y int) {
-	invItem := g.Inventory[y][x]
+	g.handleGridLeftClick(g.Inventory, x, y, 0, nil)
+}
+
+// HandleContainerLeftClick is HandleInventoryLeftClick for the open containers slots
+func (g *GUI) HandleContainerLeftClick(x, y int) {
+	g.handleGridLeftClick(g.Container.Slots(), x, y, ContainerRowOffset, g.Container.CanPut)
+}
+
+// handleGridLeftClick picks up, puts down, merges or swaps the item in slot x, y of grid, the slots are drawn
+// rowOffset rows above the bottom of the inventory. canPut says what can go in a slot, nil if anything can.
+func (g *GUI) handleGridLeftClick(grid [][]*InventoryItem, x, y, rowOffset int, canPut func(x, y int, item *InventoryItem) bool) {
+	invItem := grid[y][x]
+	allowed := g.HoldingInvItem != nil && (canPut == nil || canPut(x, y, g.HoldingInvItem))
 
 	// if invItem is nil it means we're clicking into an inventory spot with nothing in it
 	if invItem == nil {
 		// if we're holding an item, we should place it there and stop holding it
-		if g.HoldingInvItem != nil {
-			g.HoldingInvItem.InventoryPosition = pixel.V(float64(x), float64(y))
+		if allowed {
+			g.HoldingInvItem.InventoryPosition = pixel.V(float64(x), float64(y+rowOffset))
 			g.HoldingInvItem.ShouldUseDrawPosition = false
 			g.HoldingInvItem.Count.Orig = g.HoldingInvItem.GetDrawPosition(g.Window)
-			g.Inventory[y][x] = g.HoldingInvItem
+			grid[y][x] = g.HoldingInvItem
 			g.HoldingInvItem = nil
 		}
 	} else {
 		if g.HoldingInvItem != nil {
 			// if invItem isn't nil, it means we're trying to either merge stacks or toggle between holding what is under the mouse cursor
-			if invItem.ItemType == g.HoldingInvItem.ItemType {
-				// merge stacks
-				invItem.Amount += g.HoldingInvItem.Amount
-				g.HoldingInvItem = nil
-			} else {
+			if invItem.CanStackWith(g.HoldingInvItem) && invItem.Room() > 0 {
+				if allowed {
+					g.mergeHeldInto(invItem)
+				}
+			} else if allowed {
 				toDrop := g.HoldingInvItem
 				toDrop.InventoryPosition = invItem.InventoryPosition
 				toDrop.Count.Orig = g.HoldingInvItem.GetDrawPosition(g.Window)
@@ -334,18 +504,71 @@ func (g *GUI) HandleInventoryLeftClick(x, y int) {
 				g.HoldingInvItem = toPickup
 
 				toDrop.ShouldUseDrawPosition = false
-				g.Inventory[y][x] = toDrop
+				grid[y][x] = toDrop
 			}
 		} else {
 			g.HoldingInvItem = invItem
 			g.HoldingInvItem.ShouldUseDrawPosition = true
-			g.Inventory[y][x] = nil
+			grid[y][x] = nil
 		}
 	}
 }
 
+// QuickMoveToContainer moves as much of the stack in slot x, y of the inventory into the open container as it takes
+func (g *GUI) QuickMoveToContainer(x, y int) {
+	item := g.Inventory[y][x]
+	if item == nil {
+		return
+	}
+
+	item.Amount = g.Container.Add(item.Key(), item.Amount, item.Meta)
+	if item.Amount <= 0 {
+		g.Inventory[y][x] = nil
+	}
+}
+
+// QuickMoveToInventory moves as much of the stack in slot x, y of the open container into the inventory as fits
+func (g *GUI) QuickMoveToInventory(x, y int) {
+	slots := g.Container.Slots()
+	item := slots[y][x]
+	if item == nil {
+		return
+	}
+
+	item.Amount = AddItemToGrid(g.Inventory, item.Key(), item.Amount, item.Meta, 0)
+	if item.Amount <= 0 {
+		slots[y][x] = nil
+	}
+}
+
+// mergeHeldInto moves as much of the held stack onto item as fits, whatever is left over goes back into the inventory
+func (g *GUI) mergeHeldInto(item *InventoryItem) {
+	moved := min(g.HoldingInvItem.Amount, item.Room())
+	item.Amount += moved
+	g.HoldingInvItem.Amount -= moved
+
+	if g.HoldingInvItem.Amount <= 0 {
+		g.HoldingInvItem = nil
+		return
+	}
+
+	g.ReturnHeldItem()
+}
+
 func (g *GUI) HandleInventoryRightClick(x, y int) {
-	invItem := g.Inventory[y][x]
+	g.handleGridRightClick(g.Inventory, x, y, 0, nil)
+}
+
+// HandleContainerRightClick is HandleInventoryRightClick for the open containers slots
+func (g *GUI) HandleContainerRightClick(x, y int) {
+	g.handleGridRightClick(g.Container.Slots(), x, y, ContainerRowOffset, g.Container.CanPut)
+}
+
+// handleGridRightClick splits the stack in slot x, y of grid or puts one of the held item in it, the slots are drawn
+// rowOffset rows above the bottom of the inventory. canPut says what can go in a slot, nil if anything can.
+func (g *GUI) handleGridRightClick(grid [][]*InventoryItem, x, y, rowOffset int, canPut func(x, y int, item *InventoryItem) bool) {
+	invItem := grid[y][x]
+	allowed := g.HoldingInvItem != nil && (canPut == nil || canPut(x, y, g.HoldingInvItem))
 
 	// if invItem is nil it means we're clicking into an inventory spot with nothing in it
 	if invItem != nil {
@@ -355,6 +578,7 @@ func (g *GUI) HandleInventoryRightClick(x, y int) {
 				invItem.Amount -= half
 
 				newItem := NewInventoryItem(invItem.UnderlyingType, invItem.ItemType, invItem.Frame, half, invItem.InventoryPosition)
+				newItem.Meta = invItem.Meta.Clone()
 				newItem.ShouldUseDrawPosition = true
 				newItem.Count.Clear()
 				newItem.Count.WriteString(strconv.Itoa(half))
@@ -362,7 +586,7 @@ func (g *GUI) HandleInventoryRightClick(x, y int) {
 			}
 		} else {
 			// if invItem isn't nil and we're right clicking and holding something, we should try to add one to it
-			if g.HoldingInvItem.ItemType == invItem.ItemType {
+			if allowed && g.HoldingInvItem.CanStackWith(invItem) && invItem.Room() > 0 {
 				if g.HoldingInvItem.Amount > 0 {
 					g.HoldingInvItem.Amount -= 1
 					g.HoldingInvItem.Count.Clear()
@@ -377,13 +601,14 @@ func (g *GUI) HandleInventoryRightClick(x, y int) {
 		}
 	} else {
 		// if invItem is nil we should create a 1 amount here and subtract from current
-		if g.HoldingInvItem != nil {
+		if allowed {
 			if g.HoldingInvItem.Amount > 0 {
-				newItem := NewInventoryItem(g.HoldingInvItem.UnderlyingType, g.HoldingInvItem.ItemType, g.HoldingInvItem.Frame, 1, pixel.V(float64(x), float64(y)))
+				newItem := NewInventoryItem(g.HoldingInvItem.UnderlyingType, g.HoldingInvItem.ItemType, g.HoldingInvItem.Frame, 1, pixel.V(float64(x), float64(y+rowOffset)))
+				newItem.Meta = g.HoldingInvItem.Meta.Clone()
 				newItem.Count.Orig = newItem.GetDrawPosition(g.Window)
 				newItem.Count.Clear()
 				newItem.Count.WriteString("1")
-				g.Inventory[y][x] = newItem
+				grid[y][x] = newItem
 
 				g.HoldingInvItem.Amount -= 1
 				g.HoldingInvItem.Count.Clear()
@@ -409,6 +634,102 @@ func (g *GUI) ClearCraftingItems() {
 	}
 }
 
+// UpdateCraftingOutput checks the crafting grid against every recipe and shows what it would make
+func (g *GUI) UpdateCraftingOutput() {
+	r := MatchRecipe(g.CraftingSlots)
+	if r == g.CraftingRecipe {
+		return
+	}
+
+	g.CraftingRecipe = r
+	g.CraftingOutput = nil
+
+	if r == nil {
+		return
+	}
+
+	out := NewInventoryItem(r.Result.UnderlyingType, r.Result.ItemType, r.Result.Frame, r.Result.Amount, pixel.V(0, -1))
+	out.Count.Orig = out.GetCraftingPosition(g.Window, g.Scale)
+	g.CraftingOutput = out
+}
+
+// HandleCraftingOutputLeftClick picks up what the crafting grid makes and uses up one of every item in the grid
+func (g *GUI) HandleCraftingOutputLeftClick(x, y int) {
+	if x != 0 || y != -1 {
+		return
+	}
+
+	out := g.CraftingOutput
+	if out == nil {
+		return
+	}
+
+	if g.HoldingInvItem == nil {
+		held := NewInventoryItem(out.UnderlyingType, out.ItemType, out.Frame, out.Amount, pixel.V(-1, -1))
+		held.ShouldUseDrawPosition = true
+		g.HoldingInvItem = held
+	} else if g.HoldingInvItem.CanStackWith(out) && g.HoldingInvItem.Room() >= out.Amount {
+		g.HoldingInvItem.Amount += out.Amount
+	} else {
+		return
+	}
+
+	for y := 0; y < len(g.CraftingSlots); y++ {
+		for x := 0; x < len(g.CraftingSlots[y]); x++ {
+			slot := g.CraftingSlots[y][x]
+			if slot == nil {
+				continue
+			}
+
+			slot.Amount -= 1
+			if slot.Amount <= 0 {
+				g.CraftingSlots[y][x] = nil
+			}
+		}
+	}
+
+	// the grid changed so the recipe has to be matched again
+	g.CraftingRecipe = nil
+	g.CraftingOutput = nil
+}
+
+// ReturnHeldItem puts the item being dragged back into the inventory where it came from, or in the first empty slot
+// if that isn't possible
+func (g *GUI) ReturnHeldItem() {
+	i := g.HoldingInvItem
+	if i == nil {
+		return
+	}
+
+	x := int(i.InventoryPosition.X)
+	y := int(i.InventoryPosition.Y)
+
+	if y < 0 || y >= len(g.Inventory) || x < 0 || x >= len(g.Inventory[y]) || g.Inventory[y][x] != nil {
+		found := false
+		for yy := 0; yy < len(g.Inventory) && !found; yy++ {
+			for xx := 0; xx < len(g.Inventory[yy]); xx++ {
+				if g.Inventory[yy][xx] == nil {
+					x = xx
+					y = yy
+					found = true
+					break
+				}
+			}
+		}
+
+		// nowhere to put it so keep holding it
+		if !found {
+			return
+		}
+	}
+
+	i.InventoryPosition = pixel.V(float64(x), float64(y))
+	i.ShouldUseDrawPosition = false
+	i.Count.Orig = i.GetDrawPosition(g.Window)
+	g.Inventory[y][x] = i
+	g.HoldingInvItem = nil
+}
+
 func (g *GUI) HandleDeleteItemLeftClick(x, y int) {
 	if x != 2 || y != -1 {
 		return
@@ -417,6 +738,57 @@ func (g *GUI) HandleDeleteItemLeftClick(x, y int) {
 	g.HoldingInvItem = nil
 }
 
+// HandleEquipmentLeftClick puts the held item on if it's worn in the clicked equipment slot, swapping it with what was
+// worn there, or picks up what's worn there if nothing is held
+func (g *GUI) HandleEquipmentLeftClick(x, y int) {
+	if x != -3 || y < -1 || y > 2 {
+		return
+	}
+
+	slot := byte(2 - y)
+	if int(slot) >= len(g.Equipment) {
+		return
+	}
+
+	worn := g.Equipment[slot]
+	held := g.HoldingInvItem
+
+	if held == nil {
+		if worn != nil {
+			worn.ShouldUseDrawPosition = true
+			g.HoldingInvItem = worn
+			g.Equipment[slot] = nil
+		}
+		return
+	}
+
+	if !CanEquip(held, slot) {
+		return
+	}
+
+	// only one of a stack is worn, the rest stays held as long as there's nothing to swap with
+	if held.Amount > 1 {
+		if worn != nil {
+			return
+		}
+
+		held.Amount -= 1
+		meta := held.Meta.Clone()
+		held = NewInventoryItem(held.UnderlyingType, held.ItemType, held.Frame, 1, pixel.ZV)
+		held.Meta = meta
+	} else {
+		g.HoldingInvItem = nil
+		if worn != nil {
+			worn.ShouldUseDrawPosition = true
+			g.HoldingInvItem = worn
+		}
+	}
+
+	held.ShouldUseDrawPosition = false
+	held.InventoryPosition = EquipmentSlotPosition(slot)
+	g.Equipment[slot] = held
+}
+
 func (g *GUI) HandleCraftingSlotLeftClick(x, y int) {
 	// crafted items popup: 4, 4
 	// trash can: 6, 4
@@ -442,10 +814,8 @@ func (g *GUI) HandleCraftingSlotLeftClick(x, y int) {
 			g.CraftingSlots[y][x] = toDrop
 			g.HoldingInvItem = nil
 		} else {
-			if slot.ItemType == g.HoldingInvItem.ItemType {
-				// merge stacks
-				slot.Amount += g.HoldingInvItem.Amount
-				g.HoldingInvItem = nil
+			if slot.CanStackWith(g.HoldingInvItem) && slot.Room() > 0 {
+				g.mergeHeldInto(slot)
 			} else {
 				// switch it if one exists
 				toDrop := g.HoldingInvItem
@@ -485,6 +855,7 @@ func (g *GUI) HandleCraftingSlotRightClick(x, y int) {
 				invItem.Amount -= half
 
 				newItem := NewInventoryItem(invItem.UnderlyingType, invItem.ItemType, invItem.Frame, half, invItem.InventoryPosition)
+				newItem.Meta = invItem.Meta.Clone()
 				newItem.ShouldUseDrawPosition = true
 				newItem.Count.Clear()
 				newItem.Count.WriteString(strconv.Itoa(half))
@@ -492,7 +863,7 @@ func (g *GUI) HandleCraftingSlotRightClick(x, y int) {
 			}
 		} else {
 			// if invItem isn't nil and we're right clicking and holding something, we should try to add one to it
-			if g.HoldingInvItem.ItemType == invItem.ItemType {
+			if g.HoldingInvItem.CanStackWith(invItem) && invItem.Room() > 0 {
 				if g.HoldingInvItem.Amount > 0 {
 					g.HoldingInvItem.Amount -= 1
 					g.HoldingInvItem.Count.Clear()
@@ -510,6 +881,7 @@ func (g *GUI) HandleCraftingSlotRightClick(x, y int) {
 		if g.HoldingInvItem != nil {
 			if g.HoldingInvItem.Amount > 0 {
 				newItem := NewInventoryItem(g.HoldingInvItem.UnderlyingType, g.HoldingInvItem.ItemType, g.HoldingInvItem.Frame, 1, pixel.V(float64(x), float64(y)))
+				newItem.Meta = g.HoldingInvItem.Meta.Clone()
 				newItem.Count.Orig = newItem.GetCraftingPosition(g.Window, g.Scale)
 				newItem.Count.Clear()
 				newItem.Count.WriteString("1")
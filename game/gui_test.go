@@ -0,0 +1,57 @@
+package game
+
+import (
+	"github.com/gopxl/pixel/v2"
+	"testing"
+)
+
+func taggedMeta() ItemMeta {
+	return ItemMeta{Name: "lucky", Tags: map[string]string{"dyed": "red"}}
+}
+
+func TestSplittingStackClonesMeta(t *testing.T) {
+	newTestWorld(t, WorldGeneratorFlat)
+	g := &GUI{}
+
+	grid := [][]*InventoryItem{{NewInventoryItem(UnderlyingTypePlaceableBlock, BlockTypeDirt, BlockTypeDirtFrameDirt, 10, pixel.ZV)}}
+	grid[0][0].Meta = taggedMeta()
+
+	g.handleGridRightClick(grid, 0, 0, 0, nil)
+
+	held := g.HoldingInvItem
+	if held == nil || held.Amount != 5 {
+		t.Fatalf("right clicking a stack of 10 picked up %+v, want half of it", held)
+	}
+	if !held.Meta.Equal(taggedMeta()) {
+		t.Fatalf("the picked up half has meta %+v, want %+v", held.Meta, taggedMeta())
+	}
+
+	held.Meta.Tags["dyed"] = "blue"
+	if got := grid[0][0].Meta.Tags["dyed"]; got != "red" {
+		t.Fatalf("changing the tags of one half changed the other to %q", got)
+	}
+}
+
+func TestEquippingFromStackKeepsMeta(t *testing.T) {
+	newTestWorld(t, WorldGeneratorFlat)
+	g := &GUI{Equipment: make([]*InventoryItem, EquipmentSlotCount)}
+
+	g.HoldingInvItem = NewInventoryItem(UnderlyingTypeEquipment, EquipmentTypeCopperHelmet, 0, 2, pixel.ZV)
+	g.HoldingInvItem.Meta = taggedMeta()
+
+	pos := EquipmentSlotPosition(EquipmentSlotHead)
+	g.HandleEquipmentLeftClick(int(pos.X), int(pos.Y))
+
+	worn := g.Equipment[EquipmentSlotHead]
+	if worn == nil || worn.Amount != 1 || g.HoldingInvItem.Amount != 1 {
+		t.Fatalf("equipping from a stack of 2 wore %+v and left %d held", worn, g.HoldingInvItem.Amount)
+	}
+	if !worn.Meta.Equal(taggedMeta()) {
+		t.Fatalf("the worn helmet has meta %+v, want %+v", worn.Meta, taggedMeta())
+	}
+
+	worn.Meta.Tags["dyed"] = "blue"
+	if got := g.HoldingInvItem.Meta.Tags["dyed"]; got != "red" {
+		t.Fatalf("changing the tags of the worn helmet changed the held one to %q", got)
+	}
+}
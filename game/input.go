@@ -0,0 +1,25 @@
+package game
+
+import "github.com/gopxl/pixel/v2"
+
+// Input is anything that can tell if a button is held down, opengl.Window is one
+type Input interface {
+	Pressed(button pixel.Button) bool
+}
+
+// KeyState is an Input with no window behind it, set a button to true to hold it down
+type KeyState map[pixel.Button]bool
+
+func (k KeyState) Pressed(button pixel.Button) bool {
+	return k[button]
+}
+
+// Window is what the game is drawn to and reads the mouse from, opengl.Window is one. Nothing in this package imports
+// the opengl backend so the simulation builds and runs on a machine without a display.
+type Window interface {
+	pixel.Target
+	Input
+	Bounds() pixel.Rect
+	MousePosition() pixel.Vec
+	SetMatrix(pixel.Matrix)
+}
@@ -2,22 +2,49 @@ package game
 
 import (
 	"github.com/gopxl/pixel/v2"
-	"github.com/gopxl/pixel/v2/backends/opengl"
 	"github.com/gopxl/pixel/v2/ext/text"
 	"golang.org/x/image/colornames"
 	"golang.org/x/image/font"
+	"image/color"
 	"strconv"
 )
 
 const (
 	UnderlyingTypePlaceableBlock byte = 0
+	UnderlyingTypeConsumable     byte = 1
+	UnderlyingTypeEquipment      byte = 2
+	UnderlyingTypeTool           byte = 3
+	UnderlyingTypeMaterial       byte = 4
+
+	durabilityBarWidth  = 40.0
+	durabilityBarHeight = 4.0
 )
 
+// ItemKey identifies a kind of item no matter how many of it there are
+type ItemKey struct {
+	UnderlyingType byte
+	ItemType       byte
+	Frame          byte
+}
+
+// ItemStack is an amount of one kind of item that isn't in an inventory, like a block drop or a crafting result
+type ItemStack struct {
+	UnderlyingType byte
+	ItemType       byte
+	Frame          byte
+	Amount         int
+}
+
+func (s ItemStack) Key() ItemKey {
+	return ItemKey{UnderlyingType: s.UnderlyingType, ItemType: s.ItemType, Frame: s.Frame}
+}
+
 type InventoryItem struct {
 	UnderlyingType        byte
 	ItemType              byte
 	Frame                 byte
 	Amount                int
+	Meta                  ItemMeta
 	InventoryPosition     pixel.Vec
 	DrawPosition          pixel.Vec
 	ShouldUseDrawPosition bool
@@ -33,6 +60,7 @@ func NewInventoryItem(underType, itemType, frame byte, amt int, inventoryPos pix
 		Frame:             frame,
 		Amount:            amt,
 		InventoryPosition: inventoryPos,
+		Meta:              NewItemMeta(underType, itemType),
 	}
 	count := text.New(pixel.V(0, 0), Atlas)
 	count.Color = colornames.White
@@ -41,7 +69,48 @@ func NewInventoryItem(underType, itemType, frame byte, amt int, inventoryPos pix
 	return newItem
 }
 
-func (i *InventoryItem) GetCraftingPosition(win *opengl.Window, scale float64) pixel.Vec {
+func (i *InventoryItem) Key() ItemKey {
+	return ItemKey{UnderlyingType: i.UnderlyingType, ItemType: i.ItemType, Frame: i.Frame}
+}
+
+// Tool returns what the item is as a tool, nil if it isn't one
+func (i *InventoryItem) Tool() *Tool {
+	if i.UnderlyingType != UnderlyingTypeTool {
+		return nil
+	}
+
+	return GetTool(i.ItemType)
+}
+
+// Name returns the items custom name if it has one and the name of its kind otherwise
+func (i *InventoryItem) Name() string {
+	if i.Meta.Name != "" {
+		return i.Meta.Name
+	}
+
+	def := GetItemDefinition(i.UnderlyingType, i.ItemType)
+	if def == nil {
+		return ""
+	}
+
+	return def.Name
+}
+
+func (i *InventoryItem) MaxStack() int {
+	return MaxStackOf(i.UnderlyingType, i.ItemType)
+}
+
+// Room returns how many more items fit on the stack
+func (i *InventoryItem) Room() int {
+	return max(0, i.MaxStack()-i.Amount)
+}
+
+// CanStackWith returns true if o is the same kind of item with the same metadata, it doesn't check if there's room
+func (i *InventoryItem) CanStackWith(o *InventoryItem) bool {
+	return i.Key() == o.Key() && i.Meta.Equal(o.Meta) && i.MaxStack() > 1
+}
+
+func (i *InventoryItem) GetCraftingPosition(win Window, scale float64) pixel.Vec {
 	craftingOffsetX := win.Bounds().W()/2 + 16*scale
 	craftingOffsetY := win.Bounds().H()/2 + 7*scale
 
@@ -51,32 +120,63 @@ func (i *InventoryItem) GetCraftingPosition(win *opengl.Window, scale float64) p
 	return pixel.V(posX, posY)
 }
 
-func (i *InventoryItem) DrawCraftingItem(win *opengl.Window, scale float64) {
+func (i *InventoryItem) DrawCraftingItem(win Window, scale float64) {
 	pos := pixel.IM.Moved(pixel.ZV).Scaled(pixel.ZV, 3.0).Moved(i.GetCraftingPosition(win, scale))
-	Tiles[i.ItemType][0].Draw(win, pos)
+	ItemSprite(i.UnderlyingType, i.ItemType, i.Frame).Draw(win, pos)
 	i.Count.Clear()
 	i.Count.WriteString(strconv.Itoa(i.Amount))
 	i.Count.Draw(win, pixel.IM)
+	i.DrawDurability(win, i.GetCraftingPosition(win, scale))
 }
 
-func (i *InventoryItem) Draw(win *opengl.Window) {
+func (i *InventoryItem) Draw(win Window) {
 	if i.ShouldUseDrawPosition {
 		drawPos := pixel.IM.Moved(pixel.ZV).Scaled(pixel.ZV, 3.0).Moved(i.DrawPosition)
-		Tiles[i.ItemType][0].Draw(win, drawPos)
+		ItemSprite(i.UnderlyingType, i.ItemType, i.Frame).Draw(win, drawPos)
 	} else {
 		pos := i.GetDrawPosition(win)
 
 		drawPos := pixel.IM.Moved(pixel.ZV).Scaled(pixel.ZV, 3.0).Moved(pos)
-		Tiles[i.ItemType][0].Draw(win, drawPos)
+		ItemSprite(i.UnderlyingType, i.ItemType, i.Frame).Draw(win, drawPos)
 
 		i.Count.Clear()
 		i.Count.WriteString(strconv.Itoa(i.Amount))
 		i.Count.Orig = pos
 		i.Count.Draw(win, pixel.IM)
+
+		i.DrawDurability(win, pos)
+	}
+}
+
+// DrawDurability draws a bar along the bottom of the slot centered at pos showing how worn down a tool is, it's only
+// drawn once the tool has been used
+func (i *InventoryItem) DrawDurability(win Window, pos pixel.Vec) {
+	t := i.Tool()
+	if t == nil || i.Meta.Durability >= t.Durability {
+		return
 	}
+
+	// goes from green to red as the tool wears down
+	fraction := float64(i.Meta.Durability) / float64(t.Durability)
+	c := color.RGBA{R: uint8(255 * (1 - fraction)), G: uint8(255 * fraction), A: 255}
+	DrawBar(win, pixel.V(pos.X, pos.Y-20), durabilityBarWidth, durabilityBarHeight, fraction, c)
+}
+
+// DrawBar draws a bar centered at pos with a black background that's filled from the left by fraction of its width
+func DrawBar(win Window, pos pixel.Vec, width, height, fraction float64, c color.Color) {
+	if WhitePixelSprite == nil {
+		return
+	}
+
+	fraction = max(0, min(1, fraction))
+	filled := width * fraction
+	left := pos.X - width/2
+
+	WhitePixelSprite.DrawColorMask(win, pixel.IM.ScaledXY(pixel.ZV, pixel.V(width, height)).Moved(pos), colornames.Black)
+	WhitePixelSprite.DrawColorMask(win, pixel.IM.ScaledXY(pixel.ZV, pixel.V(filled, height)).Moved(pixel.V(left+filled/2, pos.Y)), c)
 }
 
-func (i *InventoryItem) GetDrawPosition(win *opengl.Window) pixel.Vec {
+func (i *InventoryItem) GetDrawPosition(win Window) pixel.Vec {
 	scale := 4.0
 	posX := i.InventoryPosition.X * 16 * scale
 	posY := i.InventoryPosition.Y * 16 * scale
@@ -87,7 +187,53 @@ func (i *InventoryItem) GetDrawPosition(win *opengl.Window) pixel.Vec {
 	return pixel.V(offsetX+posX, offsetY+posY+36)
 }
 
-func GetInventoryItemDrawPosition(win *opengl.Window, x, y int) pixel.Vec {
+// AddItemToGrid adds amount of an item to grid, topping up stacks that have room before using the next empty slot.
+// New stacks are positioned rowOffset rows up from their slot. It returns how many didn't fit.
+func AddItemToGrid(grid [][]*InventoryItem, key ItemKey, amount int, meta ItemMeta, rowOffset int) int {
+	adding := NewInventoryItem(key.UnderlyingType, key.ItemType, key.Frame, 0, pixel.ZV)
+	adding.Meta = meta
+
+	for y := 0; y < len(grid) && amount > 0; y++ {
+		for x := 0; x < len(grid[y]) && amount > 0; x++ {
+			item := grid[y][x]
+			if item == nil || !item.CanStackWith(adding) {
+				continue
+			}
+
+			moved := min(amount, item.Room())
+			item.Amount += moved
+			amount -= moved
+		}
+	}
+
+	for y := 0; y < len(grid) && amount > 0; y++ {
+		for x := 0; x < len(grid[y]) && amount > 0; x++ {
+			if grid[y][x] != nil {
+				continue
+			}
+
+			moved := min(amount, adding.MaxStack())
+			newItem := NewInventoryItem(key.UnderlyingType, key.ItemType, key.Frame, moved, pixel.V(float64(x), float64(y+rowOffset)))
+			newItem.Meta = meta.Clone()
+			grid[y][x] = newItem
+			amount -= moved
+		}
+	}
+
+	return amount
+}
+
+// ItemSprite returns the sprite used to show an item, falling back to the first frame of its type
+func ItemSprite(underType, itemType, frame byte) *pixel.Sprite {
+	def := GetItemDefinition(underType, itemType)
+	if def == nil {
+		return nil
+	}
+
+	return def.Icon(frame)
+}
+
+func GetInventoryItemDrawPosition(win Window, x, y int) pixel.Vec {
 	scale := 4.0
 	posX := float64(x) * 16 * scale
 	posY := float64(y) * 16 * scale
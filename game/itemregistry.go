@@ -0,0 +1,182 @@
+package game
+
+import (
+	"github.com/gopxl/pixel/v2"
+	"maps"
+)
+
+const (
+	ItemCategoryBlock     byte = 0
+	ItemCategoryFood      byte = 1
+	ItemCategoryEquipment byte = 2
+	ItemCategoryTool      byte = 3
+	ItemCategoryMaterial  byte = 4
+)
+
+// how many of an item fit in one slot unless its definition says otherwise
+var DefaultMaxStack = map[byte]int{
+	ItemCategoryBlock:     64,
+	ItemCategoryFood:      32,
+	ItemCategoryEquipment: 1,
+	ItemCategoryTool:      1,
+	ItemCategoryMaterial:  64,
+}
+
+// ItemID identifies a kind of item, unlike ItemKey it doesn't care which frame of a block it is
+type ItemID struct {
+	UnderlyingType byte
+	ItemType       byte
+}
+
+// ItemDefinition is everything every item of one kind has in common
+type ItemDefinition struct {
+	ID       ItemID
+	Name     string
+	Category byte
+	MaxStack int
+	Icons    map[byte]*pixel.Sprite // by frame
+}
+
+// ItemMeta is what belongs to a single item instead of its kind, items only stack if their metadata is the same
+type ItemMeta struct {
+	Durability int               `json:",omitempty"` // hits left if the item is a tool
+	Name       string            `json:",omitempty"` // shown instead of the name from its definition
+	Tags       map[string]string `json:",omitempty"` // anything else
+}
+
+var ItemDefinitions = map[ItemID]*ItemDefinition{}
+
+// LoadItemDefinitions registers every block, consumable, piece of equipment, tool and material as an item, it has to be called
+// once all of their sprites are loaded
+func LoadItemDefinitions() {
+	defs := map[ItemID]*ItemDefinition{}
+
+	for id, b := range BlockDefinitions {
+		def := &ItemDefinition{
+			ID:       ItemID{UnderlyingTypePlaceableBlock, id},
+			Name:     b.Name,
+			Category: ItemCategoryBlock,
+			MaxStack: b.MaxStack,
+			Icons:    b.Sprites,
+		}
+		defs[def.ID] = def
+	}
+
+	for id, c := range Consumables {
+		def := &ItemDefinition{
+			ID:       ItemID{UnderlyingTypeConsumable, id},
+			Name:     c.Name,
+			Category: ItemCategoryFood,
+			MaxStack: c.MaxStack,
+			Icons:    map[byte]*pixel.Sprite{0: c.Sprite},
+		}
+		defs[def.ID] = def
+	}
+
+	for id, e := range Equipments {
+		def := &ItemDefinition{
+			ID:       ItemID{UnderlyingTypeEquipment, id},
+			Name:     e.Name,
+			Category: ItemCategoryEquipment,
+			Icons:    map[byte]*pixel.Sprite{0: e.Sprite},
+		}
+		defs[def.ID] = def
+	}
+
+	for id, t := range Tools {
+		def := &ItemDefinition{
+			ID:       ItemID{UnderlyingTypeTool, id},
+			Name:     t.Name,
+			Category: ItemCategoryTool,
+			Icons:    map[byte]*pixel.Sprite{0: t.Sprite},
+		}
+		defs[def.ID] = def
+	}
+
+	for id, m := range Materials {
+		def := &ItemDefinition{
+			ID:       ItemID{UnderlyingTypeMaterial, id},
+			Name:     m.Name,
+			Category: ItemCategoryMaterial,
+			MaxStack: m.MaxStack,
+			Icons:    map[byte]*pixel.Sprite{0: m.Sprite},
+		}
+		defs[def.ID] = def
+	}
+
+	for _, def := range defs {
+		if def.MaxStack <= 0 {
+			def.MaxStack = DefaultMaxStack[def.Category]
+		}
+	}
+
+	ItemDefinitions = defs
+}
+
+func GetItemDefinition(underType, itemType byte) *ItemDefinition {
+	return ItemDefinitions[ItemID{underType, itemType}]
+}
+
+// GetItemDefinitionByName returns the definition of the item called name, nil if there isn't one
+func GetItemDefinitionByName(name string) *ItemDefinition {
+	for _, def := range ItemDefinitions {
+		if def.Name == name {
+			return def
+		}
+	}
+
+	return nil
+}
+
+// Icon returns the sprite for a frame, falling back to the first frame
+func (d *ItemDefinition) Icon(frame byte) *pixel.Sprite {
+	sprite, ok := d.Icons[frame]
+	if !ok {
+		return d.Icons[0]
+	}
+
+	return sprite
+}
+
+// MaxStackOf returns how many of an item fit in one slot, unknown items don't stack
+func MaxStackOf(underType, itemType byte) int {
+	def := GetItemDefinition(underType, itemType)
+	if def == nil {
+		return 1
+	}
+
+	return def.MaxStack
+}
+
+// NewItemMeta returns the metadata a new item of a kind starts with
+func NewItemMeta(underType, itemType byte) ItemMeta {
+	meta := ItemMeta{}
+
+	if underType == UnderlyingTypeTool {
+		if t := GetTool(itemType); t != nil {
+			meta.Durability = t.Durability
+		}
+	}
+
+	return meta
+}
+
+// loadedMeta returns saved metadata for an item of a kind, anything saved before it existed keeps its new value
+func loadedMeta(underType, itemType byte, saved ItemMeta) ItemMeta {
+	if saved.Durability <= 0 {
+		saved.Durability = NewItemMeta(underType, itemType).Durability
+	}
+
+	return saved
+}
+
+// Clone returns a copy of m that doesn't share its tags, for when a stack is split in two
+func (m ItemMeta) Clone() ItemMeta {
+	m.Tags = maps.Clone(m.Tags)
+
+	return m
+}
+
+func (m ItemMeta) Equal(o ItemMeta) bool {
+	return m.Durability == o.Durability && m.Name == o.Name && maps.Equal(m.Tags, o.Tags)
+}
@@ -0,0 +1,70 @@
+package game
+
+import (
+	"github.com/gopxl/pixel/v2"
+	"math/rand/v2"
+)
+
+// LootTable is what a generated container can be filled with, it's picked from Rolls times
+type LootTable struct {
+	Rolls   [2]int       `json:"rolls"` // how many stacks at least and at most
+	Entries []*LootEntry `json:"entries"`
+}
+
+// LootEntry is one kind of item a loot table can give, items are referred to by name
+type LootEntry struct {
+	Item   string `json:"item"`
+	Frame  byte   `json:"frame"`
+	Min    int    `json:"min"`
+	Max    int    `json:"max"`
+	Weight int    `json:"weight"` // how likely it is compared to the other entries, 1 if not set
+
+	Key ItemKey `json:"-"`
+}
+
+// Fill puts a random pick of the tables items in random empty slots of c, anything that doesn't fit is lost
+func (t *LootTable) Fill(c Container, rnd *rand.Rand) {
+	total := 0
+	for _, e := range t.Entries {
+		total += e.Weight
+	}
+	if total <= 0 {
+		return
+	}
+
+	rolls := t.Rolls[0] + rnd.IntN(max(0, t.Rolls[1]-t.Rolls[0])+1)
+	for i := 0; i < rolls; i++ {
+		n := rnd.IntN(total)
+		for _, e := range t.Entries {
+			if n < e.Weight {
+				putLoot(c, rnd, e.Key, e.Min+rnd.IntN(e.Max-e.Min+1))
+				break
+			}
+			n -= e.Weight
+		}
+	}
+}
+
+// putLoot puts amount of key in a random empty slot of c, falling back to wherever it fits
+func putLoot(c Container, rnd *rand.Rand, key ItemKey, amount int) {
+	item := NewInventoryItem(key.UnderlyingType, key.ItemType, key.Frame, amount, pixel.ZV)
+
+	slots := c.Slots()
+	empty := []IntVec{}
+	for y := 0; y < len(slots); y++ {
+		for x := 0; x < len(slots[y]); x++ {
+			if slots[y][x] == nil && c.HasSlot(x, y) && c.CanPut(x, y, item) {
+				empty = append(empty, NewIntVec(x, y))
+			}
+		}
+	}
+
+	if len(empty) == 0 || amount > item.MaxStack() {
+		c.Add(key, amount, item.Meta)
+		return
+	}
+
+	slot := empty[rnd.IntN(len(empty))]
+	item.InventoryPosition = ContainerSlotPosition(slot.X, slot.Y)
+	slots[slot.Y][slot.X] = item
+}
@@ -2,24 +2,44 @@ package game
 
 import (
 	"github.com/gopxl/pixel/v2"
-	"github.com/gopxl/pixel/v2/backends/opengl"
+	"log"
+	"math"
+	"math/rand/v2"
+	"sort"
 )
 
 type Map struct {
 	Name            string
+	Seed            uint64 // every chunk is generated from this so the same seed always makes the same world
+	Generator       WorldGenerator
 	Chunks          map[int]map[int]*Chunk
 	Spritesheets    map[string]*Spritesheet
 	FloorBatch      *pixel.Batch // the holder for batch drawing
 	TreeBatchBottom *pixel.Batch
 	TreeBatchTop    *pixel.Batch
 	DrawRadius      float64   // how many chunks around the current center chunk should be drawn
+	UnloadPadding   float64   // how many chunks past DrawRadius stay loaded so walking over a chunk border doesn't reload them
+	MaxLoadedChunks int       // the memory budget as a number of chunks, chunks outside DrawRadius are evicted furthest first when more than this are loaded
 	ChunkPosition   pixel.Vec // the current center chunk
+	Spawn           pixel.Vec // where players without a bed respawn
+	DrawDirty       bool      // set when the map batches have to be put back together from the chunks
+	drawnPosition   pixel.Vec // the center chunk the map batches were last put together around
+	randomTicks     float64   // random ticks per chunk owed since the last whole one
 }
 
-func NewMap(name string, s *Spritesheet) (*Map, error) {
+// NewMap returns the map for the world called name, chunks that haven't been saved are made by the world generator
+// called generator
+func NewMap(name string, seed uint64, generator string, s *Spritesheet) (*Map, error) {
+	gen, err := NewWorldGenerator(generator, seed)
+	if err != nil {
+		return nil, err
+	}
+
 	return &Map{
-		Name:   name,
-		Chunks: map[int]map[int]*Chunk{},
+		Name:      name,
+		Seed:      seed,
+		Generator: gen,
+		Chunks:    map[int]map[int]*Chunk{},
 		Spritesheets: map[string]*Spritesheet{
 			"all": s,
 		},
@@ -27,7 +47,10 @@ func NewMap(name string, s *Spritesheet) (*Map, error) {
 		TreeBatchBottom: pixel.NewBatch(&pixel.TrianglesData{}, s.Picture),
 		TreeBatchTop:    pixel.NewBatch(&pixel.TrianglesData{}, s.Picture),
 		DrawRadius:      4,
+		UnloadPadding:   2,
+		MaxLoadedChunks: 256,
 		ChunkPosition:   pixel.V(0, 0),
+		DrawDirty:       true,
 	}, nil
 }
 
@@ -55,28 +78,170 @@ func (m *Map) BlockExists(chunk, coords IntVec) bool {
 	return true
 }
 
-func (m *Map) GenerateChunksAroundPlayer(g *Game, win *opengl.Window) {
+func (m *Map) GenerateChunksAroundPlayer() {
 	for y := m.ChunkPosition.Y - m.DrawRadius; y < m.ChunkPosition.Y+m.DrawRadius; y++ {
 		for x := m.ChunkPosition.X - m.DrawRadius; x < m.ChunkPosition.X+m.DrawRadius; x++ {
-			// check if chunk exists
-			_, yExists := m.Chunks[int(y)]
+			m.LoadOrGenerateChunk(int(x), int(y))
+		}
+	}
+}
 
-			if yExists {
-				_, xExists := m.Chunks[int(y)][int(x)]
+// LoadOrGenerateChunk makes sure the chunk at x, y is loaded, reading it from disk if it has been saved before and
+// generating it otherwise
+func (m *Map) LoadOrGenerateChunk(x, y int) *Chunk {
+	// check if chunk exists
+	if c := m.GetChunk(x, y); c != nil {
+		return c
+	}
 
-				if xExists {
-					continue
-				}
-			}
+	// load the chunk from disk if it has been saved before
+	c, err := m.LoadChunk(x, y)
+	if err != nil {
+		// the broken file is kept out of the way of the new chunk so whatever was built there can still be recovered,
+		// if it can't be moved the chunk isn't loaded at all rather than being generated over it
+		if moveErr := m.MoveChunkAside(x, y); moveErr != nil {
+			log.Printf("chunk %d, %d couldn't be loaded or moved aside: %v, %v\n", x, y, err, moveErr)
+			return nil
+		}
+		log.Printf("chunk %d, %d couldn't be loaded so it was moved aside and generated again: %v\n", x, y, err)
+	}
+	if c != nil {
+		m.SetChunk(c)
+		return c
+	}
+
+	// generate chunk
+	m.GenerateChunk(x, y, true)
+
+	return m.GetChunk(x, y)
+}
+
+// RespawnPosition returns where p respawns, at their bed if it's still there and at the world spawn otherwise
+func (m *Map) RespawnPosition(p *Player) pixel.Vec {
+	if p.SpawnBlock != nil {
+		b := m.TopBlockAt(*p.SpawnBlock)
+		if b != nil && b.Type == BlockTypeBed {
+			return b.Position
+		}
+
+		// the bed is gone
+		p.SpawnBlock = nil
+	}
+
+	return m.Spawn
+}
+
+// TopBlockAt returns the block on top of the stack at map block coordinates, loading its chunk if it has to
+func (m *Map) TopBlockAt(block IntVec) *Block {
+	chunkX := int(math.Floor(float64(block.X) / 16))
+	chunkY := int(math.Floor(float64(block.Y) / 16))
+
+	c := m.LoadOrGenerateChunk(chunkX, chunkY)
+	if c == nil {
+		return nil
+	}
+
+	stack := c.Blocks[block.Y-chunkY*16][block.X-chunkX*16]
+	if len(stack) == 0 {
+		return nil
+	}
+
+	return stack[len(stack)-1]
+}
+
+// LoadedTopBlockAt is TopBlockAt without loading anything, it returns false if the chunk isn't loaded
+func (m *Map) LoadedTopBlockAt(block IntVec) (*Block, bool) {
+	chunkX := int(math.Floor(float64(block.X) / 16))
+	chunkY := int(math.Floor(float64(block.Y) / 16))
+
+	c := m.GetChunk(chunkX, chunkY)
+	if c == nil {
+		return nil, false
+	}
+
+	return c.TopBlock(NewIntVec(block.X-chunkX*16, block.Y-chunkY*16)), true
+}
+
+// UnloadDistantChunks saves and forgets every chunk that is further than DrawRadius plus UnloadPadding from all of
+// centers, then evicts the furthest chunks outside DrawRadius until no more than MaxLoadedChunks are loaded. With no
+// centers nothing is unloaded, so a server with nobody online doesn't save and drop every chunk.
+func (m *Map) UnloadDistantChunks(centers ...pixel.Vec) {
+	if len(centers) == 0 {
+		return
+	}
+
+	type loadedChunk struct {
+		chunk    *Chunk
+		distance float64 // in chunks to the closest center
+	}
+
+	loaded := []loadedChunk{}
+	for _, row := range m.Chunks {
+		for _, c := range row {
+			loaded = append(loaded, loadedChunk{c, m.chunkDistance(c, centers)})
+		}
+	}
+
+	kept := []loadedChunk{}
+	for _, l := range loaded {
+		if l.distance > m.DrawRadius+m.UnloadPadding {
+			m.UnloadChunk(l.chunk)
+		} else {
+			kept = append(kept, l)
+		}
+	}
+
+	if m.MaxLoadedChunks <= 0 || len(kept) <= m.MaxLoadedChunks {
+		return
+	}
+
+	sort.Slice(kept, func(i, j int) bool {
+		return kept[i].distance > kept[j].distance
+	})
+
+	for i := 0; i < len(kept)-m.MaxLoadedChunks; i++ {
+		if kept[i].distance <= m.DrawRadius {
+			break
+		}
+
+		m.UnloadChunk(kept[i].chunk)
+	}
+}
+
+// chunkDistance returns how many chunks c is from the closest of centers, measured the same way as DrawRadius
+func (m *Map) chunkDistance(c *Chunk, centers []pixel.Vec) float64 {
+	closest := math.Inf(1)
 
-			// generate chunk
-			m.GenerateAllDirtChunk(win, int(x), int(y), true, g)
+	for _, center := range centers {
+		// chunks are loaded from center - DrawRadius up to but not including center + DrawRadius
+		dx := center.X - float64(c.X)
+		if dx <= 0 {
+			dx = float64(c.X) - center.X + 1
 		}
+		dy := center.Y - float64(c.Y)
+		if dy <= 0 {
+			dy = float64(c.Y) - center.Y + 1
+		}
+
+		closest = math.Min(closest, math.Max(dx, dy))
+	}
+
+	return closest
+}
+
+// UnloadChunk saves c and removes it from the map, it stays loaded if it can't be saved so nothing is lost
+func (m *Map) UnloadChunk(c *Chunk) {
+	if err := m.SaveChunk(c); err != nil {
+		log.Println(err)
+		return
 	}
+
+	m.RemoveChunk(c.X, c.Y)
 }
 
-func (m *Map) GenerateAllDirtChunk(win *opengl.Window, x, y int, force bool, g *Game) {
-	newChunk := NewChunk(win, x, y, 16, 16, "grass", g)
+// GenerateChunk generates the chunk at x, y, replacing the one that's already loaded there only if force is set
+func (m *Map) GenerateChunk(x, y int, force bool) {
+	newChunk := m.Generator.GenerateChunk(x, y, 16, 16)
 
 	_, yExists := m.Chunks[y]
 	if !yExists {
@@ -91,57 +256,205 @@ func (m *Map) GenerateAllDirtChunk(win *opengl.Window, x, y int, force bool, g *
 			m.Chunks[y][x] = newChunk
 		}
 	}
+
+	m.DrawDirty = true
 }
 
-// RefreshDrawBatch loads the chunks around the maps center chunk using
-func (m *Map) RefreshDrawBatch() {
-	m.FloorBatch.Clear()
+func (m *Map) SetChunk(c *Chunk) {
+	_, yExists := m.Chunks[c.Y]
+	if !yExists {
+		m.Chunks[c.Y] = map[int]*Chunk{}
+	}
+
+	m.Chunks[c.Y][c.X] = c
+	m.DrawDirty = true
+}
+
+func (m *Map) GetChunk(x, y int) *Chunk {
+	row, yExists := m.Chunks[y]
+	if !yExists {
+		return nil
+	}
+
+	return row[x]
+}
+
+// RemoveChunk forgets the chunk at x, y and takes its blocks out of collision checks
+func (m *Map) RemoveChunk(x, y int) {
+	c := m.GetChunk(x, y)
+	if c == nil {
+		return
+	}
+
+	for _, row := range c.Blocks {
+		for _, stack := range row {
+			for _, b := range stack {
+				RemoveCollideable(b)
+			}
+		}
+	}
+
+	delete(m.Chunks[y], x)
+	if len(m.Chunks[y]) == 0 {
+		delete(m.Chunks, y)
+	}
+
+	m.DrawDirty = true
+}
+
+// PlaceBlock puts a block on top of the stack at coords in chunk, it returns false if there is nothing to place it on
+func (m *Map) PlaceBlock(chunk, coords IntVec, blockType, frame byte) bool {
+	if !m.BlockExists(chunk, coords) {
+		return false
+	}
+
+	// if last block in stack is the same, don't double place
+	c := m.Chunks[chunk.Y][chunk.X]
+	stack := c.Blocks[coords.Y][coords.X]
+	if len(stack) == 0 {
+		return false
+	}
+	if stack[len(stack)-1].Type == blockType {
+		return false
+	}
+
+	// nothing goes on top of a block with an entity so it can still be used, or on water
+	if stack[len(stack)-1].Entity != nil || stack[len(stack)-1].IsLiquid() {
+		return false
+	}
+
+	b := NewBlock(blockType, frame, stack[0].Position)
+	c.Blocks[coords.Y][coords.X] = append(stack, b)
+	c.AddEntityBlock(b)
+	c.Dirty = true
+
+	if b.IsSolid() {
+		AddCollideable(b)
+	}
+
+	if ticker, ok := blockTickers[b.Type]; ok && ticker.OnPlace != nil {
+		ticker.OnPlace(m, c, coords, b)
+	}
+
+	return true
+}
+
+// CanHitBlock returns true if there's a block on top of the ground at coords in chunk that can be broken
+func (m *Map) CanHitBlock(chunk, coords IntVec) bool {
+	if !m.BlockExists(chunk, coords) {
+		return false
+	}
+
+	return len(m.Chunks[chunk.Y][chunk.X].Blocks[coords.Y][coords.X]) > 1
+}
+
+// HitBlock damages the top block at coords in chunk with tool, nil for a bare hand, once it breaks it's removed and
+// its drops are spawned. The ground at the bottom of a stack can't be broken. It returns true if a block was broken.
+func (m *Map) HitBlock(chunk, coords IntVec, tool *Tool) bool {
+	if !m.CanHitBlock(chunk, coords) {
+		return false
+	}
+
+	c := m.Chunks[chunk.Y][chunk.X]
+	stack := c.Blocks[coords.Y][coords.X]
+
+	top := stack[len(stack)-1]
+	if !top.Hit(tool) {
+		return false
+	}
+
+	c.Blocks[coords.Y][coords.X] = stack[:len(stack)-1]
+	c.RemoveEntityBlock(top)
+	c.Dirty = true
+
+	if top.IsSolid() {
+		RemoveCollideable(top)
+	}
+
+	top.SpawnDrops()
+
+	return true
+}
+
+// HarvestBlock picks whatever can be picked off the top block at coords in chunk without breaking it, like the
+// berries on a bush. It returns true if anything was harvested.
+func (m *Map) HarvestBlock(chunk, coords IntVec) bool {
+	if !m.BlockExists(chunk, coords) {
+		return false
+	}
+
+	c := m.Chunks[chunk.Y][chunk.X]
+	stack := c.Blocks[coords.Y][coords.X]
+	top := stack[len(stack)-1]
+
+	if top.Type != BlockTypeBush || top.Frame != BlockTypeBushFrameBerries {
+		return false
+	}
+
+	top.Frame = BlockTypeBushFrameBare
+	c.Dirty = true
+
+	for i := 0; i < 2; i++ {
+		velocity := pixel.V(rand.Float64()*60-30, rand.Float64()*60-30)
+		SpawnFloater(UnderlyingTypeConsumable, ConsumableTypeBerries, 0, top.Position, velocity)
+	}
 
-	treeTops := []pixel.Vec{} // so we can redraw in reverse later because drawing from top to bottom causes overlapping issue
+	return true
+}
+
+// TickBlockEntities ticks the block entities in every loaded chunk
+func (m *Map) TickBlockEntities(dt float64) {
+	for _, row := range m.Chunks {
+		for _, c := range row {
+			c.TickEntities(dt)
+		}
+	}
+}
+
+// RefreshDrawBatch puts the map batches back together from the cached batches of the chunks around the maps center
+// chunk, it only does anything if a chunk has changed or the center chunk has moved
+func (m *Map) RefreshDrawBatch() {
+	visible := []*Chunk{}
 
-	// load tiles into batch around player
 	for y := m.ChunkPosition.Y - m.DrawRadius; y < m.ChunkPosition.Y+m.DrawRadius; y++ {
 		for x := m.ChunkPosition.X - m.DrawRadius; x < m.ChunkPosition.X+m.DrawRadius; x++ {
-			_, yExists := m.Chunks[int(y)]
-			if !yExists {
+			c := m.GetChunk(int(x), int(y))
+			if c == nil {
 				continue
 			}
 
-			_, xExists := m.Chunks[int(y)][int(x)]
-			if !xExists {
-				continue
+			if c.Dirty {
+				m.DrawDirty = true
 			}
 
-			for ty := 0; ty < 16; ty++ {
-				for tx := 0; tx < 16; tx++ {
-					// get tile
-					tiles, tileExists := m.Chunks[int(y)][int(x)].Blocks[ty][tx]
-					if !tileExists {
-						continue
-					}
-
-					// add tile to batch
-					for i := 0; i < len(tiles); i++ {
-						tile := tiles[i]
-
-						if tile.Type == BlockTypeTree {
-							treeTops = append(treeTops, tile.GetPosition())
-							Tiles[BlockTypeTree][BlockTypeTreeFrameGrownBottom].Draw(m.TreeBatchBottom, pixel.IM.Moved(tile.GetPosition()))
-						} else {
-							Tiles[tile.Type][tile.Frame].Draw(m.FloorBatch, pixel.IM.Moved(tile.GetPosition()))
-						}
-					}
-				}
-			}
+			visible = append(visible, c)
 		}
 	}
 
-	// add tree tops to their batch
-	for i := len(treeTops) - 1; i >= 0; i-- {
-		Tiles[BlockTypeTree][BlockTypeTreeFrameGrownTop].Draw(m.TreeBatchTop, pixel.IM.Moved(treeTops[i]))
+	if !m.DrawDirty && m.drawnPosition == m.ChunkPosition {
+		return
 	}
+
+	m.FloorBatch.Clear()
+	m.TreeBatchBottom.Clear()
+	m.TreeBatchTop.Clear()
+
+	pic := m.Spritesheets["all"].Picture
+	for _, c := range visible {
+		c.RefreshDrawBatch(pic)
+		c.FloorBatch.Draw(m.FloorBatch)
+		c.TreeBatchBottom.Draw(m.TreeBatchBottom)
+	}
+
+	// tree tops are added in reverse so the ones further down are drawn over the ones above them
+	for i := len(visible) - 1; i >= 0; i-- {
+		visible[i].TreeBatchTop.Draw(m.TreeBatchTop)
+	}
+
+	m.DrawDirty = false
+	m.drawnPosition = m.ChunkPosition
 }
 
-func (m *Map) Draw(win *opengl.Window) {
+func (m *Map) Draw(win Window) {
 
 }
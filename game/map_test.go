@@ -0,0 +1,72 @@
+package game
+
+import (
+	"github.com/gopxl/pixel/v2"
+	"os"
+	"testing"
+)
+
+func loadedChunks(m *Map) int {
+	n := 0
+	for _, row := range m.Chunks {
+		n += len(row)
+	}
+
+	return n
+}
+
+func TestUnloadDistantChunks(t *testing.T) {
+	w := newTestWorld(t, WorldGeneratorFlat)
+	m := w.Map
+	m.GenerateChunksAroundPlayer()
+
+	loaded := loadedChunks(m)
+	if loaded == 0 {
+		t.Fatal("no chunks were generated around the player")
+	}
+
+	// nobody to keep chunks loaded around, like an empty server
+	m.UnloadDistantChunks()
+	if got := loadedChunks(m); got != loaded {
+		t.Fatalf("unloading with no centers left %d of %d chunks loaded", got, loaded)
+	}
+
+	m.UnloadDistantChunks(pixel.V(0, 0))
+	if got := loadedChunks(m); got != loaded {
+		t.Fatalf("unloading around the center the chunks were generated around left %d of %d chunks loaded", got, loaded)
+	}
+
+	m.UnloadDistantChunks(pixel.V(100, 100))
+	if got := loadedChunks(m); got != 0 {
+		t.Fatalf("%d chunks are still loaded after moving 100 chunks away", got)
+	}
+	if _, err := os.Stat(m.ChunkPath(0, 0)); err != nil {
+		t.Fatalf("the unloaded chunk at 0, 0 wasn't saved: %v", err)
+	}
+}
+
+func TestUnloadDistantChunksKeepsBudget(t *testing.T) {
+	w := newTestWorld(t, WorldGeneratorFlat)
+	m := w.Map
+	m.UnloadPadding = 100
+	m.MaxLoadedChunks = 80
+
+	for y := -10; y < 10; y++ {
+		for x := -10; x < 10; x++ {
+			m.LoadOrGenerateChunk(x, y)
+		}
+	}
+
+	m.UnloadDistantChunks(pixel.V(0, 0))
+	if got := loadedChunks(m); got != m.MaxLoadedChunks {
+		t.Fatalf("%d chunks are loaded, want the budget of %d", got, m.MaxLoadedChunks)
+	}
+
+	// the chunks being drawn are never evicted even if they're over budget
+	m.MaxLoadedChunks = 1
+	m.UnloadDistantChunks(pixel.V(0, 0))
+	drawn := int(2 * m.DrawRadius * 2 * m.DrawRadius)
+	if got := loadedChunks(m); got != drawn {
+		t.Fatalf("%d chunks are loaded, want the %d inside the draw radius", got, drawn)
+	}
+}
@@ -0,0 +1,52 @@
+package game
+
+import "github.com/gopxl/pixel/v2"
+
+const (
+	MaterialTypeCopperIngot byte = 0
+	MaterialTypeCharcoal    byte = 1
+	MaterialTypeIronIngot   byte = 2
+	MaterialTypeBucket      byte = 3
+)
+
+// Material is an item that's used to make or collect other things
+type Material struct {
+	Name     string
+	Frame    [4]float64 // x, y, w, h in the tile sheet measured from the top left
+	MaxStack int        // how many fit in one inventory slot, 0 for the default
+	Sprite   *pixel.Sprite
+}
+
+var Materials = map[byte]*Material{
+	MaterialTypeCopperIngot: {
+		Name:  "copper ingot",
+		Frame: [4]float64{48, 176, 16, 16},
+	},
+	MaterialTypeCharcoal: {
+		Name:  "charcoal",
+		Frame: [4]float64{64, 176, 16, 16},
+	},
+	MaterialTypeIronIngot: {
+		Name:  "iron ingot",
+		Frame: [4]float64{80, 176, 16, 16},
+	},
+	MaterialTypeBucket: {
+		Name:     "bucket",
+		Frame:    [4]float64{96, 176, 16, 16},
+		MaxStack: 16,
+	},
+}
+
+// LoadMaterialSprites cuts the sprite of every material out of the tile sheet
+func LoadMaterialSprites(s *Spritesheet) {
+	h := s.Picture.Bounds().H()
+
+	for _, m := range Materials {
+		f := m.Frame
+		m.Sprite = pixel.NewSprite(s.Picture, pixel.R(f[0], h-f[1], f[0]+f[2], h-f[1]-f[3]))
+	}
+}
+
+func GetMaterial(itemType byte) *Material {
+	return Materials[itemType]
+}
@@ -0,0 +1,103 @@
+package game
+
+import (
+	"math"
+	"math/rand/v2"
+)
+
+// Noise is seeded 2D Perlin noise, points close together get similar values so it's used for anything that should
+// change smoothly across the world
+type Noise struct {
+	perm [512]uint8 // a shuffle of 0-255 repeated twice so lookups don't have to wrap
+}
+
+// NewNoise returns the noise for seed, salt picks one of many unrelated noises for the same seed
+func NewNoise(seed, salt uint64) *Noise {
+	rnd := rand.New(rand.NewPCG(seed, salt))
+	p := rnd.Perm(256)
+
+	n := &Noise{}
+	for i := range n.perm {
+		n.perm[i] = uint8(p[i&255])
+	}
+
+	return n
+}
+
+// At returns the noise at x, y, roughly between -1 and 1. Features are about one unit across so coordinates should be
+// scaled down to make them bigger.
+func (n *Noise) At(x, y float64) float64 {
+	fx := math.Floor(x)
+	fy := math.Floor(y)
+	xi := int(fx) & 255
+	yi := int(fy) & 255
+
+	// where the point is inside its cell
+	x -= fx
+	y -= fy
+
+	u := fade(x)
+	v := fade(y)
+
+	a := int(n.perm[xi]) + yi
+	b := int(n.perm[xi+1]) + yi
+
+	bottom := lerp(u, grad(n.perm[a], x, y), grad(n.perm[b], x-1, y))
+	top := lerp(u, grad(n.perm[a+1], x, y-1), grad(n.perm[b+1], x-1, y-1))
+
+	return lerp(v, bottom, top)
+}
+
+// Fractal adds octaves of noise together, each one twice as detailed and half as strong as the last, so there are
+// big features with smaller ones on top. It's between -1 and 1 like At.
+func (n *Noise) Fractal(x, y float64, octaves int) float64 {
+	total := 0.0
+	amplitude := 1.0
+	sum := 0.0
+
+	for i := 0; i < octaves; i++ {
+		total += n.At(x, y) * amplitude
+		sum += amplitude
+
+		amplitude /= 2
+		x *= 2
+		y *= 2
+	}
+
+	if sum == 0 {
+		return 0
+	}
+
+	return total / sum
+}
+
+// fade eases t so the noise is smooth where cells meet
+func fade(t float64) float64 {
+	return t * t * t * (t*(t*6-15) + 10)
+}
+
+func lerp(t, a, b float64) float64 {
+	return a + t*(b-a)
+}
+
+// grad returns the dot product of x, y with one of eight gradients picked by hash
+func grad(hash uint8, x, y float64) float64 {
+	switch hash & 7 {
+	case 0:
+		return x + y
+	case 1:
+		return x - y
+	case 2:
+		return -x + y
+	case 3:
+		return -x - y
+	case 4:
+		return x
+	case 5:
+		return -x
+	case 6:
+		return y
+	default:
+		return -y
+	}
+}
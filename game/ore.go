@@ -0,0 +1,195 @@
+package game
+
+import (
+	"math/rand/v2"
+	"slices"
+)
+
+const OreRegionSize = 32 // veins are planned for every square of this many tiles on their own
+
+// Ore is a block that's generated in veins on top of the ground
+type Ore struct {
+	Block          byte
+	Frame          byte
+	VeinsPerRegion map[byte]float64 // by biome, how many veins start in a region on average, unlisted biomes have none
+	VeinMin        int              // how many tiles a vein covers at least
+	VeinMax        int              // and at most, veins never reach further than this from where they start
+	Grounds        []byte           // the ground blocks it can be on, any if empty
+}
+
+var Ores = []*Ore{
+	{
+		Block: BlockTypeStone,
+		Frame: BlockTypeStoneFrame1,
+		VeinsPerRegion: map[byte]float64{
+			BiomePlains: 1,
+			BiomeForest: 0.5,
+			BiomeRocky:  6,
+			BiomeDesert: 1.5,
+		},
+		VeinMin: 3,
+		VeinMax: 8,
+	},
+	{
+		Block: BlockTypeCopper,
+		Frame: BlockTypeCopperFrame1,
+		VeinsPerRegion: map[byte]float64{
+			BiomePlains: 0.5,
+			BiomeForest: 0.5,
+			BiomeRocky:  2.5,
+			BiomeDesert: 0.8,
+		},
+		VeinMin: 2,
+		VeinMax: 6,
+	},
+	{
+		Block: BlockTypeCoal,
+		Frame: BlockTypeCoalFrame1,
+		VeinsPerRegion: map[byte]float64{
+			BiomePlains: 0.3,
+			BiomeForest: 0.8,
+			BiomeRocky:  1.5,
+		},
+		VeinMin: 3,
+		VeinMax: 7,
+		Grounds: []byte{BlockTypeGrass, BlockTypeDirt},
+	},
+	{
+		Block: BlockTypeIron,
+		Frame: BlockTypeIronFrame1,
+		VeinsPerRegion: map[byte]float64{
+			BiomeRocky:  1.2,
+			BiomeDesert: 0.4,
+		},
+		VeinMin: 2,
+		VeinMax: 4,
+	},
+}
+
+// PlaceOres puts every ore vein that reaches into c on top of its bare ground, biomeAt is the id of the biome at every
+// map block coordinate. Veins are planned per region from the seed alone so a vein that crosses a chunk border carries on the
+// same way in the next chunk no matter which one is generated first.
+func PlaceOres(c *Chunk, seed uint64, biomeAt func(x, y int) byte) {
+	minX := c.X * c.W
+	minY := c.Y * c.H
+	maxX := minX + c.W - 1
+	maxY := minY + c.H - 1
+
+	for i, ore := range Ores {
+		// every region a vein could reach the chunk from
+		rx0 := floorDiv(minX-ore.VeinMax, OreRegionSize)
+		ry0 := floorDiv(minY-ore.VeinMax, OreRegionSize)
+		rx1 := floorDiv(maxX+ore.VeinMax, OreRegionSize)
+		ry1 := floorDiv(maxY+ore.VeinMax, OreRegionSize)
+
+		for ry := ry0; ry <= ry1; ry++ {
+			for rx := rx0; rx <= rx1; rx++ {
+				for _, tile := range ore.regionVeins(seed, uint64(i), rx, ry, biomeAt) {
+					if tile.X < minX || tile.X > maxX || tile.Y < minY || tile.Y > maxY {
+						continue
+					}
+
+					ore.place(c, tile.X-minX, tile.Y-minY)
+				}
+			}
+		}
+	}
+}
+
+// regionVeins returns the map block coordinates of every tile of the veins that start in region rx, ry
+func (o *Ore) regionVeins(seed, salt uint64, rx, ry int, biomeAt func(x, y int) byte) []IntVec {
+	most := 0.0
+	for _, n := range o.VeinsPerRegion {
+		most = max(most, n)
+	}
+	if most <= 0 {
+		return nil
+	}
+
+	rnd := rand.New(rand.NewPCG(seed^(salt+1)*0x9e3779b97f4a7c15, mixChunkCoords(rx, ry)))
+
+	// as many veins are planned as the richest biome would get, then each one is kept depending on the biome it
+	// starts in
+	veins := int(most)
+	if rnd.Float64() < most-float64(veins) {
+		veins++
+	}
+
+	tiles := []IntVec{}
+	for v := 0; v < veins; v++ {
+		start := NewIntVec(rx*OreRegionSize+rnd.IntN(OreRegionSize), ry*OreRegionSize+rnd.IntN(OreRegionSize))
+		size := o.VeinMin + rnd.IntN(o.VeinMax-o.VeinMin+1)
+		keep := rnd.Float64()
+
+		// the whole vein is walked even if it isn't kept so the random numbers stay in step
+		vein := walkVein(rnd, start, size, o.VeinMax)
+
+		if keep*most < o.VeinsPerRegion[biomeAt(start.X, start.Y)] {
+			tiles = append(tiles, vein...)
+		}
+	}
+
+	return tiles
+}
+
+// walkVein wanders from start until it has covered size tiles, never going further than reach from start
+func walkVein(rnd *rand.Rand, start IntVec, size, reach int) []IntVec {
+	tiles := []IntVec{start}
+	pos := start
+
+	for steps := 0; len(tiles) < size && steps < size*4; steps++ {
+		next := pos
+		switch rnd.IntN(4) {
+		case 0:
+			next.X++
+		case 1:
+			next.X--
+		case 2:
+			next.Y++
+		default:
+			next.Y--
+		}
+
+		if abs(next.X-start.X) >= reach || abs(next.Y-start.Y) >= reach {
+			continue
+		}
+
+		pos = next
+		if !slices.Contains(tiles, pos) {
+			tiles = append(tiles, pos)
+		}
+	}
+
+	return tiles
+}
+
+// place puts the ore at tx, ty in c if there's nothing but the right kind of ground there
+func (o *Ore) place(c *Chunk, tx, ty int) {
+	stack := c.Blocks[ty][tx]
+	if len(stack) != 1 {
+		return
+	}
+
+	if stack[0].IsLiquid() || (len(o.Grounds) > 0 && !slices.Contains(o.Grounds, stack[0].Type)) {
+		return
+	}
+
+	c.Blocks[ty][tx] = append(stack, NewBlock(o.Block, o.Frame, stack[0].Position))
+}
+
+func floorDiv(a, b int) int {
+	q := a / b
+	if a%b != 0 && (a < 0) != (b < 0) {
+		q--
+	}
+
+	return q
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+
+	return n
+}
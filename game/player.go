@@ -2,9 +2,9 @@ package game
 
 import (
 	"github.com/gopxl/pixel/v2"
-	"github.com/gopxl/pixel/v2/backends/opengl"
 	"image"
 	"math"
+	"math/rand/v2"
 )
 
 const (
@@ -15,6 +15,12 @@ const (
 
 	PlayerWalking byte = 0
 	PlayerRunning byte = 1
+
+	PlayerMaxHealth = 100.0
+	PlayerMaxHunger = 100.0
+	PlayerMaxThirst = 100.0
+
+	WaterThirst = 20.0 // thirst restored by drinking straight from water
 )
 
 type Player struct {
@@ -27,6 +33,7 @@ type Player struct {
 	SwingFrames         map[byte][]*pixel.Sprite
 	FrameSpeed          map[byte]float64
 	Inventory           [][]*InventoryItem
+	Equipment           []*InventoryItem // what's worn in each equipment slot, nil for nothing
 	InventoryW          int
 	InventoryH          int
 	HotbarX             int
@@ -45,10 +52,21 @@ type Player struct {
 	MouseRectImage      *image.RGBA
 	MouseRectSprite     *pixel.Sprite
 	MaxPlaceDistance    float64
+	InventoryChanged    bool // set whenever the inventory is changed by the world so it can be synced
+	Health              float64
+	Hunger              float64          // 0 is starving
+	Thirst              float64          // 0 is dehydrated
+	HungerRate          map[byte]float64 // lost per second
+	ThirstRate          map[byte]float64 // lost per second
+	StarveDamage        float64          // health lost per second for each of hunger and thirst that is empty
+	RegenThreshold      float64          // health comes back while hunger and thirst are both at least this
+	RegenRate           float64          // health gained per second
+	Dead                bool
+	SpawnBlock          *IntVec // map block coordinates of the bed the player respawns at, nil for the world spawn
 }
 
-func NewPlayer(win *opengl.Window) (*Player, error) {
-	s, err := NewSpritesheet("./assets/player/character.png")
+func NewPlayer() (*Player, error) {
+	s, err := NewSpritesheet(AssetPath("player/character.png"))
 	if err != nil {
 		return nil, err
 	}
@@ -56,7 +74,7 @@ func NewPlayer(win *opengl.Window) (*Player, error) {
 	//w := s.Picture.Bounds().W()
 	h := s.Picture.Bounds().H()
 
-	mSprite := MakeDebugRect(win, 16, 16)
+	mSprite := MakeDebugRect(16, 16)
 
 	p := &Player{
 		Position: pixel.V(0, 0),
@@ -126,7 +144,7 @@ func NewPlayer(win *opengl.Window) (*Player, error) {
 		CurrentFrame:        0,
 		MaxMovementFrame:    4,
 		Solid:               true,
-		DebugRect:           MakeDebugRect(win, 16, 16),
+		DebugRect:           MakeDebugRect(16, 16),
 		MovementDirections:  []byte{},
 		Inventory:           [][]*InventoryItem{},
 		InventoryW:          7,
@@ -134,10 +152,25 @@ func NewPlayer(win *opengl.Window) (*Player, error) {
 		ShouldDrawInventory: false,
 		MouseRectSprite:     mSprite,
 		MaxPlaceDistance:    3,
+		Health:              PlayerMaxHealth,
+		Hunger:              PlayerMaxHunger,
+		Thirst:              PlayerMaxThirst,
+		HungerRate: map[byte]float64{
+			PlayerWalking: 0.1,
+			PlayerRunning: 0.25,
+		},
+		ThirstRate: map[byte]float64{
+			PlayerWalking: 0.15,
+			PlayerRunning: 0.4,
+		},
+		StarveDamage:   1,
+		RegenThreshold: 80,
+		RegenRate:      0.5,
 	}
 
 	p.ClearInventory()
-	p.AddInventoryItem(NewInventoryItem(UnderlyingTypePlaceableBlock, BlockTypeDirt, BlockTypeDirtFrameDirt, 100, pixel.V(0, 0)))
+	p.ClearEquipment()
+	p.AddItemToInventory(ItemKey{UnderlyingTypePlaceableBlock, BlockTypeDirt, BlockTypeDirtFrameDirt}, 100, ItemMeta{})
 
 	return p, nil
 }
@@ -177,57 +210,65 @@ func (p *Player) IsMovingInDirection(d byte) bool {
 	return false
 }
 
-func (p *Player) Update(win *opengl.Window, dt float64) {
-	if win.Pressed(pixel.KeyA) {
+// Update moves the player using the movement keys held down in input
+func (p *Player) Update(input Input, dt float64) {
+	if input.Pressed(pixel.KeyA) {
 		p.AddMovementDirection(PlayerDirectionLeft)
 	} else {
 		p.RemoveMovementDirection(PlayerDirectionLeft)
 	}
 
-	if win.Pressed(pixel.KeyD) {
+	if input.Pressed(pixel.KeyD) {
 		p.AddMovementDirection(PlayerDirectionRight)
 	} else {
 		p.RemoveMovementDirection(PlayerDirectionRight)
 	}
 
-	if win.Pressed(pixel.KeyW) {
+	if input.Pressed(pixel.KeyW) {
 		p.AddMovementDirection(PlayerDirectionUp)
 	} else {
 		p.RemoveMovementDirection(PlayerDirectionUp)
 	}
 
-	if win.Pressed(pixel.KeyS) {
+	if input.Pressed(pixel.KeyS) {
 		p.AddMovementDirection(PlayerDirectionDown)
 	} else {
 		p.RemoveMovementDirection(PlayerDirectionDown)
 	}
 
+	p.UpdateMovement(dt)
+}
+
+// UpdateMovement moves and animates the player using whatever is currently in MovementDirections
+func (p *Player) UpdateMovement(dt float64) {
 	p.OldPosition = p.Position
 
+	speed := p.Speed[p.WalkingOrRunning] * p.SpeedMultiplier()
+
 	if p.IsMovingInDirection(PlayerDirectionUp) {
 		p.MovementDirection = PlayerDirectionUp
 
-		p.Position.Y += p.Speed[p.WalkingOrRunning] * dt
+		p.Position.Y += speed * dt
 		if p.IsMovingInDirection(PlayerDirectionLeft) {
-			p.Position.X -= p.Speed[p.WalkingOrRunning] / 2 * dt
+			p.Position.X -= speed / 2 * dt
 		} else if p.IsMovingInDirection(PlayerDirectionRight) {
-			p.Position.X += p.Speed[p.WalkingOrRunning] / 2 * dt
+			p.Position.X += speed / 2 * dt
 		}
 	} else if p.IsMovingInDirection(PlayerDirectionDown) {
 		p.MovementDirection = PlayerDirectionDown
 
-		p.Position.Y -= p.Speed[p.WalkingOrRunning] * dt
+		p.Position.Y -= speed * dt
 		if p.IsMovingInDirection(PlayerDirectionLeft) {
-			p.Position.X -= p.Speed[p.WalkingOrRunning] / 2 * dt
+			p.Position.X -= speed / 2 * dt
 		} else if p.IsMovingInDirection(PlayerDirectionRight) {
-			p.Position.X += p.Speed[p.WalkingOrRunning] / 2 * dt
+			p.Position.X += speed / 2 * dt
 		}
 	} else if p.IsMovingInDirection(PlayerDirectionLeft) {
 		p.MovementDirection = PlayerDirectionLeft
-		p.Position.X -= p.Speed[p.WalkingOrRunning] * dt
+		p.Position.X -= speed * dt
 	} else if p.IsMovingInDirection(PlayerDirectionRight) {
 		p.MovementDirection = PlayerDirectionRight
-		p.Position.X += p.Speed[p.WalkingOrRunning] * dt
+		p.Position.X += speed * dt
 	}
 
 	if len(p.MovementDirections) > 0 && !p.IsSwinging {
@@ -246,6 +287,218 @@ func (p *Player) Update(win *opengl.Window, dt float64) {
 	}
 }
 
+// UpdateStats drains hunger and thirst, faster while running, and hurts or heals the player depending on them
+func (p *Player) UpdateStats(dt float64) {
+	rate := PlayerWalking
+	if p.WalkingOrRunning == PlayerRunning && len(p.MovementDirections) > 0 {
+		rate = PlayerRunning
+	}
+
+	p.Hunger = math.Max(0, p.Hunger-p.HungerRate[rate]*dt)
+	p.Thirst = math.Max(0, p.Thirst-p.ThirstRate[rate]*dt)
+
+	// armor doesn't help against starving
+	if p.Hunger <= 0 {
+		p.Health = math.Max(0, p.Health-p.StarveDamage*dt)
+	}
+	if p.Thirst <= 0 {
+		p.Health = math.Max(0, p.Health-p.StarveDamage*dt)
+	}
+
+	if p.Health > 0 && p.Hunger >= p.RegenThreshold && p.Thirst >= p.RegenThreshold {
+		p.Health = math.Min(PlayerMaxHealth, p.Health+p.RegenRate*dt)
+	}
+}
+
+// Damage hurts the player by amount less whatever their armor takes away
+func (p *Player) Damage(amount float64) {
+	p.Health = math.Max(0, p.Health-amount*(1-p.Armor()))
+}
+
+// Armor returns the fraction of damage taken away by everything the player is wearing
+func (p *Player) Armor() float64 {
+	armor := 0.0
+	for _, item := range p.Equipment {
+		if e := p.equipped(item); e != nil {
+			armor += e.Armor
+		}
+	}
+
+	return math.Min(MaxArmor, math.Max(0, armor))
+}
+
+// SpeedMultiplier returns what the players speed is multiplied by because of what they're wearing
+func (p *Player) SpeedMultiplier() float64 {
+	speed := 1.0
+	for _, item := range p.Equipment {
+		if e := p.equipped(item); e != nil {
+			speed += e.Speed
+		}
+	}
+
+	return math.Max(0.1, speed)
+}
+
+func (p *Player) equipped(item *InventoryItem) *Equipment {
+	if item == nil || item.UnderlyingType != UnderlyingTypeEquipment {
+		return nil
+	}
+
+	return GetEquipment(item.ItemType)
+}
+
+func (p *Player) ClearEquipment() {
+	p.Equipment = make([]*InventoryItem, EquipmentSlotCount)
+}
+
+// Equip puts item on in slot and returns what was worn there before, it returns item back if it can't be worn there
+func (p *Player) Equip(item *InventoryItem, slot byte) *InventoryItem {
+	if int(slot) >= len(p.Equipment) || !CanEquip(item, slot) {
+		return item
+	}
+
+	old := p.Equipment[slot]
+	item.InventoryPosition = EquipmentSlotPosition(slot)
+	p.Equipment[slot] = item
+	p.InventoryChanged = true
+
+	return old
+}
+
+// Unequip takes off whatever is worn in slot and returns it
+func (p *Player) Unequip(slot byte) *InventoryItem {
+	if int(slot) >= len(p.Equipment) {
+		return nil
+	}
+
+	old := p.Equipment[slot]
+	p.Equipment[slot] = nil
+	p.InventoryChanged = true
+
+	return old
+}
+
+// Die drops the whole inventory where the player is standing and takes them out of the world until they respawn
+func (p *Player) Die() {
+	if p.Dead {
+		return
+	}
+
+	items := append([]*InventoryItem{}, p.Equipment...)
+	for y := 0; y < len(p.Inventory); y++ {
+		items = append(items, p.Inventory[y]...)
+	}
+
+	for _, item := range items {
+		if item == nil || item.Amount <= 0 {
+			continue
+		}
+
+		velocity := pixel.V(rand.Float64()*60-30, rand.Float64()*60-30)
+		f := SpawnFloater(item.UnderlyingType, item.ItemType, item.Frame, p.Position, velocity)
+		f.Amount = item.Amount
+		f.Meta = item.Meta
+	}
+
+	p.ClearInventory()
+	p.ClearEquipment()
+	p.InventoryChanged = true
+
+	p.Dead = true
+	p.Health = 0
+	p.MovementDirections = []byte{}
+	p.IsSwinging = false
+
+	RemoveCollideable(p)
+}
+
+// Respawn brings a dead player back at pos with full stats
+func (p *Player) Respawn(pos pixel.Vec) {
+	p.Position = pos
+	p.OldPosition = pos
+	p.Health = PlayerMaxHealth
+	p.Hunger = PlayerMaxHunger
+	p.Thirst = PlayerMaxThirst
+	p.Dead = false
+
+	AddCollideable(p)
+}
+
+// SetSpawnAt makes the bed at map block coordinates block the players spawn point, it returns false if there's no
+// bed there
+func (p *Player) SetSpawnAt(m *Map, block IntVec) bool {
+	b := m.TopBlockAt(block)
+	if b == nil || b.Type != BlockTypeBed {
+		return false
+	}
+
+	p.SpawnBlock = &block
+
+	return true
+}
+
+// Eat uses up one of item if it's a consumable and restores the players stats with it
+func (p *Player) Eat(item *InventoryItem) bool {
+	if item == nil || item.Amount <= 0 || item.UnderlyingType != UnderlyingTypeConsumable {
+		return false
+	}
+
+	c := GetConsumable(item.ItemType)
+	if c == nil {
+		return false
+	}
+
+	p.Health = math.Min(PlayerMaxHealth, p.Health+c.Health)
+	p.Hunger = math.Min(PlayerMaxHunger, p.Hunger+c.Hunger)
+	p.Thirst = math.Min(PlayerMaxThirst, p.Thirst+c.Thirst)
+
+	item.Amount -= 1
+	if item.Amount <= 0 {
+		p.RemoveInventoryItem(item)
+	}
+	p.InventoryChanged = true
+
+	if c.Returns != nil {
+		p.GiveItem(*c.Returns)
+	}
+
+	return true
+}
+
+// GiveItem puts one of key in the inventory, it's dropped at the players feet if there's no room
+func (p *Player) GiveItem(key ItemKey) {
+	meta := NewItemMeta(key.UnderlyingType, key.ItemType)
+	if p.AddItemToInventory(key, 1, meta) > 0 {
+		f := SpawnFloater(key.UnderlyingType, key.ItemType, key.Frame, p.Position, pixel.ZV)
+		f.Meta = meta
+	}
+}
+
+// UseWaterAt drinks from the water at map block coordinates block, or fills the held bucket if there is one. It
+// returns false if there's no water there.
+func (p *Player) UseWaterAt(m *Map, block IntVec) bool {
+	b := m.TopBlockAt(block)
+	if b == nil || b.Type != BlockTypeWater {
+		return false
+	}
+
+	held := p.GetHeldItem()
+	if held == nil || held.UnderlyingType != UnderlyingTypeMaterial || held.ItemType != MaterialTypeBucket {
+		p.Thirst = math.Min(PlayerMaxThirst, p.Thirst+WaterThirst)
+		return true
+	}
+
+	held.Amount -= 1
+	if held.Amount <= 0 {
+		p.RemoveInventoryItem(held)
+	}
+	p.InventoryChanged = true
+
+	p.GiveItem(ItemKey{UnderlyingTypeConsumable, ConsumableTypeWaterBucket, 0})
+
+	return true
+}
+
 func (p *Player) Draw(game *Game) {
 	currentFrame := int(math.Floor(p.CurrentFrame))
 
@@ -257,13 +510,20 @@ func (p *Player) Draw(game *Game) {
 		}
 	}
 
+	pos := p.InterpolatedPosition(game.Alpha)
 	if p.IsSwinging {
-		p.SwingFrames[p.MovementDirection][currentFrame].Draw(game.Window, pixel.IM.Moved(p.Position))
+		p.SwingFrames[p.MovementDirection][currentFrame].Draw(game.Window, pixel.IM.Moved(pos))
 	} else {
-		p.Frames[p.MovementDirection][currentFrame].Draw(game.Window, pixel.IM.Moved(p.Position))
+		p.Frames[p.MovementDirection][currentFrame].Draw(game.Window, pixel.IM.Moved(pos))
 	}
 
 	game.GUI.SetHotbarItems(p.Inventory[0], p.HotbarX)
+	game.GUI.SetStats(p.Health, p.Hunger, p.Thirst)
+}
+
+// InterpolatedPosition returns the position alpha of the way from the last tick to the current one
+func (p *Player) InterpolatedPosition(alpha float64) pixel.Vec {
+	return pixel.Lerp(p.OldPosition, p.Position, alpha)
 }
 
 func (p *Player) GetChunkPosition() pixel.Vec {
@@ -308,8 +568,12 @@ func (p *Player) Collide(c Collideable) {
 		f := c.(*Floater)
 
 		if !f.Deleted {
-			p.AddItemToInventory(f.UnderlyingType, f.ItemType, f.Frame)
-			f.Deleted = true
+			// whatever doesn't fit in the inventory is left on the ground
+			f.Amount = p.AddItemToInventory(f.Key(), f.Amount, f.Meta)
+
+			if f.Amount <= 0 {
+				f.Deleted = true
+			}
 		}
 	}
 }
@@ -326,16 +590,21 @@ func (p *Player) GetOldPosition() pixel.Vec {
 	return p.OldPosition
 }
 
-func (p *Player) DrawDebug(win *opengl.Window) {
-	p.DebugRect.Draw(win, pixel.IM.Moved(p.Position))
+func (p *Player) DrawDebug(t pixel.Target) {
+	p.DebugRect.Draw(t, pixel.IM.Moved(p.Position))
 }
 
 func (p *Player) ButtonCallback(game *Game, btn pixel.Button, action pixel.Action) {
+	if p.Dead {
+		return
+	}
+
 	if btn == pixel.MouseButtonLeft && action == pixel.Press {
 		if !p.InInventory {
 			if !p.IsSwinging {
 				p.CurrentFrame = 0
 				p.IsSwinging = true
+				p.BreakBlock(game)
 			}
 		}
 	} else if btn == pixel.MouseButtonRight && action == pixel.Press {
@@ -354,6 +623,22 @@ func (p *Player) HandleRightClick(game *Game) {
 		return
 	}
 
+	if p.Harvest(game) {
+		return
+	}
+
+	if p.OpenContainer(game) {
+		return
+	}
+
+	if p.UseBed(game) {
+		return
+	}
+
+	if p.UseWater(game) {
+		return
+	}
+
 	held := p.GetHeldItem()
 
 	if held == nil {
@@ -362,7 +647,62 @@ func (p *Player) HandleRightClick(game *Game) {
 
 	if held.UnderlyingType == UnderlyingTypePlaceableBlock {
 		p.PlaceBlock(game, held)
+	} else if held.UnderlyingType == UnderlyingTypeConsumable {
+		p.Eat(held)
+	}
+}
+
+// OpenContainer opens the chest or furnace under the mouse next to the inventory if it's within reach
+func (p *Player) OpenContainer(game *Game) bool {
+	coords := p.GetMouseMapBlockCoords(game)
+	if !p.CanReach(coords) {
+		return false
+	}
+
+	b := game.Map.TopBlockAt(NewIntVec(int(coords.X), int(coords.Y)))
+	if b == nil {
+		return false
+	}
+
+	c, ok := b.Entity.(Container)
+	if !ok {
+		return false
 	}
+
+	game.GUI.OpenContainer(b, c)
+	p.InInventory = true
+
+	return true
+}
+
+// UseBed sets the players spawn point to the bed under the mouse if it's within reach
+func (p *Player) UseBed(game *Game) bool {
+	coords := p.GetMouseMapBlockCoords(game)
+	if !p.CanReach(coords) {
+		return false
+	}
+
+	return p.SetSpawnAt(game.Map, NewIntVec(int(coords.X), int(coords.Y)))
+}
+
+// UseWater drinks from or fills a bucket with the water under the mouse if it's within reach
+func (p *Player) UseWater(game *Game) bool {
+	coords := p.GetMouseMapBlockCoords(game)
+	if !p.CanReach(coords) {
+		return false
+	}
+
+	return p.UseWaterAt(game.Map, NewIntVec(int(coords.X), int(coords.Y)))
+}
+
+// Harvest picks whatever can be picked off the block under the mouse if it's within reach
+func (p *Player) Harvest(game *Game) bool {
+	if !p.CanReach(p.GetMouseMapBlockCoords(game)) {
+		return false
+	}
+
+	chunk, coords := p.GetMouseMapCoords(game)
+	return game.Map.HarvestBlock(chunk, coords)
 }
 
 func (p *Player) GetMouseMapCoords(game *Game) (IntVec, IntVec) {
@@ -376,42 +716,84 @@ func (p *Player) GetMouseMapCoords(game *Game) (IntVec, IntVec) {
 	return NewIntVec(int(chunkX), int(chunkY)), NewIntVec(int(x), int(y))
 }
 
+// CanReach returns true if the block at the given map block coordinates is within the players reach
+func (p *Player) CanReach(blockCoords pixel.Vec) bool {
+	dis := blockCoords.Sub(p.GetBlockPosition().ToVec()).Len()
+	return dis < float64(p.MaxPlaceDistance)
+}
+
 func (p *Player) PlaceBlock(game *Game, item *InventoryItem) {
 	// users have a max reach distance
-	dis := p.GetMouseMapBlockCoords(game).Sub(p.GetBlockPosition().ToVec()).Len()
-	if dis >= float64(p.MaxPlaceDistance) {
+	if !p.CanReach(p.GetMouseMapBlockCoords(game)) {
 		return
 	}
 
+	chunk, coords := p.GetMouseMapCoords(game)
+	p.PlaceBlockAt(game.Map, chunk, coords, item)
+}
+
+// PlaceBlockAt places one of item on top of the block stack at coords in chunk and takes it from the inventory
+func (p *Player) PlaceBlockAt(m *Map, chunk, coords IntVec, item *InventoryItem) bool {
 	if item == nil {
-		return
+		return false
+	}
+	if item.Amount <= 0 || item.UnderlyingType != UnderlyingTypePlaceableBlock {
+		return false
 	}
+
+	if !m.PlaceBlock(chunk, coords, item.ItemType, item.Frame) {
+		return false
+	}
+
+	item.Amount -= 1
 	if item.Amount <= 0 {
+		p.RemoveInventoryItem(item)
+	}
+	p.InventoryChanged = true
+
+	return true
+}
+
+// BreakBlock hits the block under the mouse if it's within reach
+func (p *Player) BreakBlock(game *Game) {
+	if !p.CanReach(p.GetMouseMapBlockCoords(game)) {
 		return
 	}
 
 	chunk, coords := p.GetMouseMapCoords(game)
-	exists := game.Map.BlockExists(chunk, coords)
-	if !exists {
-		return
+	p.HitBlockAt(game.Map, chunk, coords)
+}
+
+// HitBlockAt hits the top block at coords in chunk with whatever tool is held, wearing the tool down. It returns true
+// if the block broke.
+func (p *Player) HitBlockAt(m *Map, chunk, coords IntVec) bool {
+	if !m.CanHitBlock(chunk, coords) {
+		return false
 	}
 
-	// if last block in stack is the same, don't double place
-	stack := game.Map.Chunks[chunk.Y][chunk.X].Blocks[coords.Y][coords.X]
-	if len(stack) == 0 {
-		return
+	held := p.GetHeldItem()
+
+	var tool *Tool
+	if held != nil {
+		tool = held.Tool()
 	}
-	if stack[len(stack)-1].Type == item.ItemType {
-		return
+
+	broken := m.HitBlock(chunk, coords, tool)
+
+	if tool != nil {
+		p.WearTool(held)
 	}
 
-	b := NewBlock(game.Window, item.ItemType, item.Frame, game.Map.Chunks[chunk.Y][chunk.X].Blocks[coords.Y][coords.X][0].Position)
-	game.Map.Chunks[chunk.Y][chunk.X].Blocks[coords.Y][coords.X] = append(game.Map.Chunks[chunk.Y][chunk.X].Blocks[coords.Y][coords.X], b)
-	item.Amount -= 1
+	return broken
+}
 
-	if item.Amount <= 0 {
+// WearTool uses up one hit of item, it breaks and is taken out of the inventory once there are none left
+func (p *Player) WearTool(item *InventoryItem) {
+	item.Meta.Durability -= 1
+	if item.Meta.Durability <= 0 {
 		p.RemoveInventoryItem(item)
 	}
+	p.InventoryChanged = true
 }
 
 func (p *Player) ClearInventory() {
@@ -436,44 +818,22 @@ func (p *Player) RemoveInventoryItem(i *InventoryItem) {
 	p.Inventory[int(i.InventoryPosition.Y)][int(i.InventoryPosition.X)] = nil
 }
 
-func (p *Player) AddItemToInventory(underType, itemType, frame byte) {
-	var foundItem *InventoryItem
-	var found bool
-	var foundX int
-	var foundY int
-	for y := 0; y < len(p.Inventory); y++ {
-		for x := 0; x < len(p.Inventory[y]); x++ {
-			item := p.Inventory[y][x]
-			if item != nil {
-				if item.ItemType == itemType {
-					foundItem = item
-					break
-				}
-			} else {
-				if !found {
-					foundX = x
-					foundY = y
-					found = true
-				}
-			}
-		}
-
-		if foundItem != nil {
-			break
-		}
+// AddItemToInventory adds amount of an item to the inventory, topping up stacks that have room before using the
+// next empty slot. It returns how many didn't fit.
+func (p *Player) AddItemToInventory(key ItemKey, amount int, meta ItemMeta) int {
+	left := AddItemToGrid(p.Inventory, key, amount, meta, 0)
+	if left != amount {
+		p.InventoryChanged = true
 	}
 
-	if foundItem != nil {
-		foundItem.Amount++
-	} else {
-		if found {
-			newInvItem := NewInventoryItem(underType, itemType, frame, 1, pixel.V(float64(foundX), float64(foundY)))
-			p.Inventory[foundY][foundX] = newInvItem
-		}
-	}
+	return left
 }
 
 func (p *Player) CharCallback(game *Game, r rune) {
+	if p.Dead {
+		return
+	}
+
 	if r >= 49 && r < 59 {
 		p.HotbarX = int(r - 49)
 	} else {
@@ -505,9 +865,8 @@ func (p *Player) ThrowInventoryItem(game *Game) {
 			delta.X = (delta.X / l) * 100.0
 			delta.Y = (delta.Y / l) * 100.0
 
-			newFloater := NewFloater(game.Window, item.UnderlyingType, item.ItemType, item.Frame, p.Position, delta)
-			Floaters = append(Floaters, newFloater)
-			AddCollideable(newFloater)
+			f := SpawnFloater(item.UnderlyingType, item.ItemType, item.Frame, p.Position, delta)
+			f.Meta = item.Meta.Clone()
 
 			if item.Amount == 0 {
 				p.Inventory[0][p.HotbarX] = nil
@@ -0,0 +1,281 @@
+package game
+
+// Recipe turns the items in the crafting grid into Result. Shaped recipes use Pattern, written top row first, where
+// every rune is looked up in Key and a space is an empty slot. The pattern can sit anywhere in the grid so it
+// shouldn't have empty rows or columns around its edges. Shapeless recipes use Ingredients instead, which can be
+// in any slot as long as nothing else is in the grid.
+type Recipe struct {
+	Pattern     []string
+	Key         map[rune]ItemKey
+	Ingredients []ItemKey
+	Result      ItemStack
+}
+
+var Recipes = []*Recipe{
+	{
+		Pattern: []string{
+			"SS",
+			"SS",
+		},
+		Key: map[rune]ItemKey{
+			'S': {UnderlyingTypePlaceableBlock, BlockTypeStone, BlockTypeStoneFrame1},
+		},
+		Result: ItemStack{UnderlyingTypePlaceableBlock, BlockTypeBrick, BlockTypeBrickFrameStone, 4},
+	},
+	{
+		Ingredients: []ItemKey{
+			{UnderlyingTypePlaceableBlock, BlockTypeBrick, BlockTypeBrickFrameStone},
+			{UnderlyingTypePlaceableBlock, BlockTypeGrass, BlockTypeGrassFrame1},
+		},
+		Result: ItemStack{UnderlyingTypePlaceableBlock, BlockTypeBrick, BlockTypeBrickFrameMossy, 1},
+	},
+	{
+		Pattern: []string{
+			"GGG",
+			"WWW",
+		},
+		Key: map[rune]ItemKey{
+			'G': {UnderlyingTypePlaceableBlock, BlockTypeGrass, BlockTypeGrassFrame1},
+			'W': {UnderlyingTypePlaceableBlock, BlockTypeWood, BlockTypeWoodFrame1},
+		},
+		Result: ItemStack{UnderlyingTypePlaceableBlock, BlockTypeBed, BlockTypeBedFrame1, 1},
+	},
+	{
+		Pattern: []string{
+			"WWW",
+			"W W",
+			"WWW",
+		},
+		Key: map[rune]ItemKey{
+			'W': {UnderlyingTypePlaceableBlock, BlockTypeWood, BlockTypeWoodFrame1},
+		},
+		Result: ItemStack{UnderlyingTypePlaceableBlock, BlockTypeChest, BlockTypeChestFrame1, 1},
+	},
+	{
+		Pattern: []string{
+			"SSS",
+			"S S",
+			"SSS",
+		},
+		Key: map[rune]ItemKey{
+			'S': {UnderlyingTypePlaceableBlock, BlockTypeStone, BlockTypeStoneFrame1},
+		},
+		Result: ItemStack{UnderlyingTypePlaceableBlock, BlockTypeFurnace, BlockTypeFurnaceFrame1, 1},
+	},
+	{
+		Pattern: []string{
+			"CCC",
+			"C C",
+		},
+		Key: map[rune]ItemKey{
+			'C': {UnderlyingTypeMaterial, MaterialTypeCopperIngot, 0},
+		},
+		Result: ItemStack{UnderlyingTypeEquipment, EquipmentTypeCopperHelmet, 0, 1},
+	},
+	{
+		Pattern: []string{
+			"C C",
+			"CCC",
+			"CCC",
+		},
+		Key: map[rune]ItemKey{
+			'C': {UnderlyingTypeMaterial, MaterialTypeCopperIngot, 0},
+		},
+		Result: ItemStack{UnderlyingTypeEquipment, EquipmentTypeCopperChestplate, 0, 1},
+	},
+	{
+		Pattern: []string{
+			"CCC",
+			"C C",
+			"C C",
+		},
+		Key: map[rune]ItemKey{
+			'C': {UnderlyingTypeMaterial, MaterialTypeCopperIngot, 0},
+		},
+		Result: ItemStack{UnderlyingTypeEquipment, EquipmentTypeCopperLeggings, 0, 1},
+	},
+	{
+		Pattern: []string{
+			"C C",
+			"C C",
+		},
+		Key: map[rune]ItemKey{
+			'C': {UnderlyingTypeMaterial, MaterialTypeCopperIngot, 0},
+		},
+		Result: ItemStack{UnderlyingTypeEquipment, EquipmentTypeCopperBoots, 0, 1},
+	},
+	{
+		Pattern: []string{
+			"G G",
+			"G G",
+		},
+		Key: map[rune]ItemKey{
+			'G': {UnderlyingTypePlaceableBlock, BlockTypeGrass, BlockTypeGrassFrame1},
+		},
+		Result: ItemStack{UnderlyingTypeEquipment, EquipmentTypeGrassSandals, 0, 1},
+	},
+	{
+		Pattern: []string{
+			"I I",
+			" I ",
+		},
+		Key: map[rune]ItemKey{
+			'I': {UnderlyingTypeMaterial, MaterialTypeIronIngot, 0},
+		},
+		Result: ItemStack{UnderlyingTypeMaterial, MaterialTypeBucket, 0, 1},
+	},
+	// tools
+	toolRecipe(ToolTypeWoodPickaxe, ItemKey{UnderlyingTypePlaceableBlock, BlockTypeWood, BlockTypeWoodFrame1}, "HHH", " W ", " W "),
+	toolRecipe(ToolTypeWoodAxe, ItemKey{UnderlyingTypePlaceableBlock, BlockTypeWood, BlockTypeWoodFrame1}, "HH", "HW", " W"),
+	toolRecipe(ToolTypeWoodShovel, ItemKey{UnderlyingTypePlaceableBlock, BlockTypeWood, BlockTypeWoodFrame1}, "H", "W"),
+	toolRecipe(ToolTypeWoodSword, ItemKey{UnderlyingTypePlaceableBlock, BlockTypeWood, BlockTypeWoodFrame1}, "H", "H", "W"),
+	toolRecipe(ToolTypeStonePickaxe, ItemKey{UnderlyingTypePlaceableBlock, BlockTypeStone, BlockTypeStoneFrame1}, "HHH", " W ", " W "),
+	toolRecipe(ToolTypeStoneAxe, ItemKey{UnderlyingTypePlaceableBlock, BlockTypeStone, BlockTypeStoneFrame1}, "HH", "HW", " W"),
+	toolRecipe(ToolTypeStoneShovel, ItemKey{UnderlyingTypePlaceableBlock, BlockTypeStone, BlockTypeStoneFrame1}, "H", "W"),
+	toolRecipe(ToolTypeStoneSword, ItemKey{UnderlyingTypePlaceableBlock, BlockTypeStone, BlockTypeStoneFrame1}, "H", "H", "W"),
+	toolRecipe(ToolTypeCopperPickaxe, ItemKey{UnderlyingTypeMaterial, MaterialTypeCopperIngot, 0}, "HHH", " W ", " W "),
+	toolRecipe(ToolTypeCopperAxe, ItemKey{UnderlyingTypeMaterial, MaterialTypeCopperIngot, 0}, "HH", "HW", " W"),
+	toolRecipe(ToolTypeCopperShovel, ItemKey{UnderlyingTypeMaterial, MaterialTypeCopperIngot, 0}, "H", "W"),
+	toolRecipe(ToolTypeCopperSword, ItemKey{UnderlyingTypeMaterial, MaterialTypeCopperIngot, 0}, "H", "H", "W"),
+}
+
+// toolRecipe makes the recipe for a tool with a wooden handle, H in pattern is head and W is wood
+func toolRecipe(toolType byte, head ItemKey, pattern ...string) *Recipe {
+	return &Recipe{
+		Pattern: pattern,
+		Key: map[rune]ItemKey{
+			'H': head,
+			'W': {UnderlyingTypePlaceableBlock, BlockTypeWood, BlockTypeWoodFrame1},
+		},
+		Result: ItemStack{UnderlyingTypeTool, toolType, 0, 1},
+	}
+}
+
+func RegisterRecipe(r *Recipe) {
+	Recipes = append(Recipes, r)
+}
+
+// MatchRecipe returns the first recipe that can be made from the grid, grid is indexed [y][x] with y = 0 at the bottom
+func MatchRecipe(grid [][]*InventoryItem) *Recipe {
+	for _, r := range Recipes {
+		if r.Matches(grid) {
+			return r
+		}
+	}
+
+	return nil
+}
+
+func (r *Recipe) IsShaped() bool {
+	return len(r.Pattern) > 0
+}
+
+func (r *Recipe) Matches(grid [][]*InventoryItem) bool {
+	if r.IsShaped() {
+		return r.matchesShaped(grid)
+	}
+
+	return r.matchesShapeless(grid)
+}
+
+func (r *Recipe) matchesShaped(grid [][]*InventoryItem) bool {
+	rows := trimCraftingGrid(grid)
+	if len(rows) != len(r.Pattern) {
+		return false
+	}
+
+	for y, line := range r.Pattern {
+		runes := []rune(line)
+		for x := 0; x < len(rows[y]) || x < len(runes); x++ {
+			var item *InventoryItem
+			if x < len(rows[y]) {
+				item = rows[y][x]
+			}
+
+			want := ' '
+			if x < len(runes) {
+				want = runes[x]
+			}
+
+			if want == ' ' {
+				if item != nil {
+					return false
+				}
+				continue
+			}
+
+			if item == nil || item.Key() != r.Key[want] {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+func (r *Recipe) matchesShapeless(grid [][]*InventoryItem) bool {
+	needed := map[ItemKey]int{}
+	for _, k := range r.Ingredients {
+		needed[k]++
+	}
+
+	for y := 0; y < len(grid); y++ {
+		for x := 0; x < len(grid[y]); x++ {
+			item := grid[y][x]
+			if item == nil {
+				continue
+			}
+
+			if needed[item.Key()] == 0 {
+				return false
+			}
+			needed[item.Key()]--
+		}
+	}
+
+	for _, n := range needed {
+		if n > 0 {
+			return false
+		}
+	}
+
+	return true
+}
+
+// trimCraftingGrid cuts the grid down to the smallest box holding every item and flips it so the top row is first,
+// which is the same way patterns are written
+func trimCraftingGrid(grid [][]*InventoryItem) [][]*InventoryItem {
+	minX, minY, maxX, maxY := -1, -1, -1, -1
+
+	for y := 0; y < len(grid); y++ {
+		for x := 0; x < len(grid[y]); x++ {
+			if grid[y][x] == nil {
+				continue
+			}
+
+			if minX == -1 || x < minX {
+				minX = x
+			}
+			if x > maxX {
+				maxX = x
+			}
+			if minY == -1 || y < minY {
+				minY = y
+			}
+			if y > maxY {
+				maxY = y
+			}
+		}
+	}
+
+	rows := [][]*InventoryItem{}
+	if minX == -1 {
+		return rows
+	}
+
+	for y := maxY; y >= minY; y-- {
+		rows = append(rows, grid[y][minX:maxX+1])
+	}
+
+	return rows
+}
@@ -0,0 +1,132 @@
+package game
+
+import (
+	"github.com/gopxl/pixel/v2"
+	"math/rand/v2"
+)
+
+// World is the simulated part of a game, the map, the player, floaters and collisions. Nothing in it needs a window
+// so it can be ticked without a display.
+type World struct {
+	Map      *Map
+	Player   *Player
+	NewWorld bool // true if the world wasn't loaded from disk
+}
+
+// OpenWorld loads the world called name from disk or creates it with the world generator called generator if it has
+// never been saved, s is the tile sheet returned by LoadAssets
+func OpenWorld(name, generator string, s *Spritesheet) (*World, error) {
+	ResetEntities()
+
+	p, err := NewPlayer()
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := LoadWorldData(name)
+	if err != nil {
+		return nil, err
+	}
+
+	// new worlds get a random seed, saved worlds keep theirs and the generator they were made with
+	seed := rand.Uint64()
+	if data != nil {
+		seed = data.Seed
+		generator = data.Generator
+	}
+
+	m, err := NewMap(name, seed, generator, s)
+	if err != nil {
+		return nil, err
+	}
+
+	w := &World{
+		Map:      m,
+		Player:   p,
+		NewWorld: data == nil,
+	}
+
+	if data != nil {
+		w.Load(data)
+	}
+
+	if !w.Player.Dead {
+		AddCollideable(w.Player)
+	}
+
+	// add an example floater at 50, 50
+	if w.NewWorld {
+		SpawnFloater(UnderlyingTypePlaceableBlock, BlockTypeDirt, BlockTypeDirtFrameDirt, pixel.V(50, 50), pixel.V(0, 0))
+	}
+
+	return w, nil
+}
+
+// Tick moves the world forward by dt seconds with the player controlled by input
+func (w *World) Tick(input Input, dt float64) {
+	w.Map.ChunkPosition = w.Player.GetChunkPosition()
+	w.Map.GenerateChunksAroundPlayer()
+	w.Map.UnloadDistantChunks(w.Map.ChunkPosition)
+
+	w.Map.TickBlocks(dt)
+	w.Map.TickBlockEntities(dt)
+	UpdateFloaters(dt)
+
+	if !w.Player.Dead {
+		w.Player.Update(input, dt)
+		w.Player.UpdateStats(dt)
+
+		if w.Player.Health <= 0 {
+			w.Player.Die()
+		}
+	}
+
+	CheckCollisions()
+}
+
+// RespawnPlayer brings the player back to life at their bed or the world spawn
+func (w *World) RespawnPlayer() {
+	if !w.Player.Dead {
+		return
+	}
+
+	w.Player.Respawn(w.Map.RespawnPosition(w.Player))
+}
+
+// FixedTimestep turns however much time really passed into ticks of the same length, so the simulation behaves the
+// same no matter how fast it's run
+type FixedTimestep struct {
+	Step        float64 // seconds simulated by every tick
+	MaxCatchUp  int     // most ticks run in one Advance, the rest of the time is dropped
+	accumulator float64
+}
+
+func NewFixedTimestep(step float64, maxCatchUp int) *FixedTimestep {
+	return &FixedTimestep{Step: step, MaxCatchUp: maxCatchUp}
+}
+
+// Advance adds elapsed seconds and calls tick once for every whole step that has built up. After a long stall only
+// MaxCatchUp ticks are run and the time that couldn't be caught up on is skipped instead of moving everything in one
+// big jump. It returns how far the time left over is into the next tick from 0 to 1, for interpolating.
+func (f *FixedTimestep) Advance(elapsed float64, tick func(dt float64)) float64 {
+	f.accumulator += elapsed
+
+	ticks := 0
+	for f.accumulator >= f.Step && ticks < f.MaxCatchUp {
+		tick(f.Step)
+		f.accumulator -= f.Step
+		ticks++
+	}
+
+	if f.accumulator >= f.Step {
+		f.accumulator = 0
+	}
+
+	return f.accumulator / f.Step
+}
+
+// ResetEntities forgets every floater and collideable so a new world can be started in the same process
+func ResetEntities() {
+	Floaters = []*Floater{}
+	Collideables = NewSpatialHash(CollisionCellSize)
+}
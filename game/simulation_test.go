@@ -0,0 +1,136 @@
+package game
+
+import (
+	"github.com/gopxl/pixel/v2"
+	"math"
+	"testing"
+)
+
+// newTestWorld opens a new world made by generator in a temporary directory with the repos assets, no window is needed
+func newTestWorld(t *testing.T, generator string) *World {
+	t.Helper()
+
+	AssetsDirectory = "../assets"
+	WorldsDirectory = t.TempDir()
+
+	s, err := LoadAssets()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w, err := OpenWorld("test", generator, s)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return w
+}
+
+func tickFor(w *World, input Input, seconds float64) {
+	for i := 0; i < int(math.Round(seconds/TickDuration)); i++ {
+		w.Tick(input, TickDuration)
+	}
+}
+
+func TestWorldTickMovesPlayer(t *testing.T) {
+	w := newTestWorld(t, WorldGeneratorFlat)
+	speed := w.Player.Speed[PlayerWalking]
+
+	tickFor(w, KeyState{pixel.KeyD: true}, 1)
+
+	if got := w.Player.Position.X; math.Abs(got-speed) > 0.01 {
+		t.Fatalf("walking right for a second moved the player to x %v, want %v", got, speed)
+	}
+
+	tickFor(w, KeyState{}, 1)
+
+	if got := w.Player.Position.X; math.Abs(got-speed) > 0.01 {
+		t.Fatalf("the player kept moving to x %v after the key was released", got)
+	}
+
+	tickFor(w, KeyState{pixel.KeyW: true}, 0.5)
+
+	if got := w.Player.Position.Y; math.Abs(got-speed/2) > 0.01 {
+		t.Fatalf("walking up for half a second moved the player to y %v, want %v", got, speed/2)
+	}
+}
+
+func TestWorldTickStopsPlayerAtTree(t *testing.T) {
+	w := newTestWorld(t, WorldGeneratorFlat)
+	tickFor(w, KeyState{}, TickDuration)
+
+	if !w.Map.PlaceBlock(NewIntVec(0, 0), NewIntVec(3, 0), BlockTypeTree, BlockTypeTreeFrameGrownTop) {
+		t.Fatal("couldn't place a tree")
+	}
+
+	tickFor(w, KeyState{pixel.KeyD: true}, 3)
+
+	if got := w.Player.Position.X; got > 3*16-16 {
+		t.Fatalf("the player walked into the tree at x %v and ended up at x %v", 3*16, got)
+	}
+}
+
+func TestWorldTickStarvesPlayer(t *testing.T) {
+	w := newTestWorld(t, WorldGeneratorFlat)
+	w.Player.Hunger = 0
+	w.Player.Thirst = 0
+
+	for i := 0; i < 60*60*10 && !w.Player.Dead; i++ {
+		w.Tick(KeyState{}, TickDuration)
+	}
+
+	if !w.Player.Dead {
+		t.Fatalf("the player is still alive with %v health after starving for ten minutes", w.Player.Health)
+	}
+}
+
+func TestPlayerDamageIsReducedByArmor(t *testing.T) {
+	w := newTestWorld(t, WorldGeneratorFlat)
+	p := w.Player
+
+	p.Damage(10)
+	if got := PlayerMaxHealth - p.Health; math.Abs(got-10) > 1e-9 {
+		t.Fatalf("10 damage without armor took %v health, want 10", got)
+	}
+
+	for _, e := range []byte{EquipmentTypeCopperHelmet, EquipmentTypeCopperChestplate, EquipmentTypeCopperLeggings, EquipmentTypeCopperBoots} {
+		p.Equip(NewInventoryItem(UnderlyingTypeEquipment, e, 0, 1, pixel.ZV), GetEquipment(e).Slot)
+	}
+
+	// the copper set takes away 0.1 + 0.25 + 0.15 + 0.1 of it
+	p.Health = PlayerMaxHealth
+	p.Damage(10)
+	if got := PlayerMaxHealth - p.Health; math.Abs(got-4) > 1e-9 {
+		t.Fatalf("10 damage in copper armor took %v health, want 4", got)
+	}
+}
+
+func TestFixedTimestep(t *testing.T) {
+	ts := NewFixedTimestep(0.05, 5)
+
+	steps := []float64{}
+	tick := func(dt float64) {
+		steps = append(steps, dt)
+	}
+
+	if alpha := ts.Advance(0.12, tick); len(steps) != 2 || math.Abs(alpha-0.4) > 1e-9 {
+		t.Fatalf("0.12s ran %d ticks and left alpha %v, want 2 ticks and 0.4", len(steps), alpha)
+	}
+	for _, dt := range steps {
+		if dt != 0.05 {
+			t.Fatalf("ticked with dt %v, want every tick to be 0.05", dt)
+		}
+	}
+
+	// a ten second stall only catches up on MaxCatchUp ticks and drops the rest
+	steps = nil
+	if alpha := ts.Advance(10, tick); len(steps) != 5 || alpha != 0 {
+		t.Fatalf("a stall ran %d ticks and left alpha %v, want 5 ticks and 0", len(steps), alpha)
+	}
+
+	steps = nil
+	ts.Advance(0.05, tick)
+	if len(steps) != 1 {
+		t.Fatalf("the next step after a stall ran %d ticks, want 1", len(steps))
+	}
+}
@@ -0,0 +1,49 @@
+package game
+
+// SmeltingRecipe turns one Input into Result after it has been in a burning furnace for Time seconds
+type SmeltingRecipe struct {
+	Input  ItemKey
+	Result ItemStack
+	Time   float64
+}
+
+var SmeltingRecipes = []*SmeltingRecipe{
+	{
+		Input:  ItemKey{UnderlyingTypePlaceableBlock, BlockTypeCopper, BlockTypeCopperFrame1},
+		Result: ItemStack{UnderlyingTypeMaterial, MaterialTypeCopperIngot, 0, 1},
+		Time:   8,
+	},
+	{
+		Input:  ItemKey{UnderlyingTypePlaceableBlock, BlockTypeIron, BlockTypeIronFrame1},
+		Result: ItemStack{UnderlyingTypeMaterial, MaterialTypeIronIngot, 0, 1},
+		Time:   12,
+	},
+	{
+		Input:  ItemKey{UnderlyingTypePlaceableBlock, BlockTypeWood, BlockTypeWoodFrame1},
+		Result: ItemStack{UnderlyingTypeMaterial, MaterialTypeCharcoal, 0, 1},
+		Time:   4,
+	},
+}
+
+// Fuels is how many seconds one of each item burns for in a furnace
+var Fuels = map[ItemKey]float64{
+	{UnderlyingTypePlaceableBlock, BlockTypeWood, BlockTypeWoodFrame1}: 12,
+	{UnderlyingTypeMaterial, MaterialTypeCharcoal, 0}:                  40,
+	{UnderlyingTypePlaceableBlock, BlockTypeCoal, BlockTypeCoalFrame1}: 40,
+}
+
+// GetSmeltingRecipe returns the recipe that smelts key, nil if it can't be smelted
+func GetSmeltingRecipe(key ItemKey) *SmeltingRecipe {
+	for _, r := range SmeltingRecipes {
+		if r.Input == key {
+			return r
+		}
+	}
+
+	return nil
+}
+
+// FuelTime returns how long one of key burns for, 0 if it isn't a fuel
+func FuelTime(key ItemKey) float64 {
+	return Fuels[key]
+}
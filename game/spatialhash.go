@@ -0,0 +1,204 @@
+package game
+
+import (
+	"github.com/gopxl/pixel/v2"
+	"math"
+)
+
+// SpatialHash buckets collideables into a grid of square cells so only things near each other are tested for collision.
+// Anything that isn't a block is treated as moving and is put back into the right cells every time collisions are checked.
+type SpatialHash struct {
+	CellSize float64
+	Cells    map[IntVec][]Collideable
+	Entries  map[Collideable]*spatialEntry
+	Dynamic  []Collideable // everything that moves, in the order it was added
+}
+
+type spatialEntry struct {
+	Min      IntVec // the cells the collideable was last put in
+	Max      IntVec
+	Position pixel.Vec
+}
+
+// collisionMargin pads queries because CollisionBBox uses the size of the second collideable for the width of both
+const collisionMargin = 16.0
+
+func NewSpatialHash(cellSize float64) *SpatialHash {
+	return &SpatialHash{
+		CellSize: cellSize,
+		Cells:    map[IntVec][]Collideable{},
+		Entries:  map[Collideable]*spatialEntry{},
+		Dynamic:  []Collideable{},
+	}
+}
+
+func (h *SpatialHash) Len() int {
+	return len(h.Entries)
+}
+
+// cellRange returns the first and last cell covered by the box at pos with size
+func (h *SpatialHash) cellRange(pos, size pixel.Vec) (IntVec, IntVec) {
+	min := NewIntVec(int(math.Floor(pos.X/h.CellSize)), int(math.Floor(pos.Y/h.CellSize)))
+	max := NewIntVec(int(math.Floor((pos.X+size.X)/h.CellSize)), int(math.Floor((pos.Y+size.Y)/h.CellSize)))
+
+	return min, max
+}
+
+func (h *SpatialHash) Insert(c Collideable) {
+	if _, exists := h.Entries[c]; exists {
+		return
+	}
+
+	pos := c.GetPosition()
+	min, max := h.cellRange(pos, c.GetSize())
+
+	for y := min.Y; y <= max.Y; y++ {
+		for x := min.X; x <= max.X; x++ {
+			cell := NewIntVec(x, y)
+			h.Cells[cell] = append(h.Cells[cell], c)
+		}
+	}
+
+	h.Entries[c] = &spatialEntry{Min: min, Max: max, Position: pos}
+
+	if c.GetType() != CollideableTypeBlock {
+		h.Dynamic = append(h.Dynamic, c)
+	}
+}
+
+func (h *SpatialHash) Remove(c Collideable) {
+	entry, exists := h.Entries[c]
+	if !exists {
+		return
+	}
+
+	h.removeFromCells(c, entry.Min, entry.Max)
+	delete(h.Entries, c)
+
+	if c.GetType() != CollideableTypeBlock {
+		for i, other := range h.Dynamic {
+			if other == c {
+				h.Dynamic = append(h.Dynamic[:i], h.Dynamic[i+1:]...)
+				break
+			}
+		}
+	}
+}
+
+func (h *SpatialHash) removeFromCells(c Collideable, min, max IntVec) {
+	for y := min.Y; y <= max.Y; y++ {
+		for x := min.X; x <= max.X; x++ {
+			cell := NewIntVec(x, y)
+			list := h.Cells[cell]
+
+			for i, other := range list {
+				if other == c {
+					list = append(list[:i], list[i+1:]...)
+					break
+				}
+			}
+
+			if len(list) == 0 {
+				delete(h.Cells, cell)
+			} else {
+				h.Cells[cell] = list
+			}
+		}
+	}
+}
+
+// Update moves a collideable into the cells for its current position if it has moved
+func (h *SpatialHash) Update(c Collideable) {
+	entry, exists := h.Entries[c]
+	if !exists {
+		return
+	}
+
+	pos := c.GetPosition()
+	if pos == entry.Position {
+		return
+	}
+
+	min, max := h.cellRange(pos, c.GetSize())
+	if min != entry.Min || max != entry.Max {
+		h.removeFromCells(c, entry.Min, entry.Max)
+
+		for y := min.Y; y <= max.Y; y++ {
+			for x := min.X; x <= max.X; x++ {
+				cell := NewIntVec(x, y)
+				h.Cells[cell] = append(h.Cells[cell], c)
+			}
+		}
+
+		entry.Min = min
+		entry.Max = max
+	}
+
+	entry.Position = pos
+}
+
+// Query returns every collideable in the cells touched by the box at pos with size, each one only once
+func (h *SpatialHash) Query(pos, size pixel.Vec) []Collideable {
+	found := []Collideable{}
+	seen := map[Collideable]bool{}
+
+	min, max := h.cellRange(pos, size)
+	for y := min.Y; y <= max.Y; y++ {
+		for x := min.X; x <= max.X; x++ {
+			for _, c := range h.Cells[NewIntVec(x, y)] {
+				if seen[c] {
+					continue
+				}
+
+				seen[c] = true
+				found = append(found, c)
+			}
+		}
+	}
+
+	return found
+}
+
+func (h *SpatialHash) ForEach(f func(Collideable)) {
+	for c := range h.Entries {
+		f(c)
+	}
+}
+
+// CheckCollisions tests everything that moves against whatever is near it, blocks never move so they're only ever
+// the second half of a pair
+func (h *SpatialHash) CheckCollisions() {
+	order := map[Collideable]int{}
+	for i, c := range h.Dynamic {
+		h.Update(c)
+		order[c] = i
+	}
+
+	// copied because collisions can remove things from the hash
+	dynamic := append([]Collideable{}, h.Dynamic...)
+
+	for i, first := range dynamic {
+		if !first.IsSolid() {
+			continue
+		}
+
+		pos := first.GetPosition().Sub(pixel.V(collisionMargin, collisionMargin))
+		size := first.GetSize().Add(pixel.V(collisionMargin*2, collisionMargin*2))
+
+		for _, second := range h.Query(pos, size) {
+			if second == first || !second.IsSolid() {
+				continue
+			}
+
+			// pairs of moving things are handled once by whichever came first
+			if j, ok := order[second]; ok && j < i {
+				continue
+			}
+
+			if CollisionBBox(first.GetPosition(), first.GetSize(), second.GetPosition(), second.GetSize()) {
+				first.Collide(second)
+				second.Collide(first)
+			}
+		}
+	}
+}
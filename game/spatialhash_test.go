@@ -0,0 +1,269 @@
+package game
+
+import (
+	"github.com/gopxl/pixel/v2"
+	"math/rand/v2"
+	"testing"
+)
+
+// testBox is a collideable that only remembers what it collided with
+type testBox struct {
+	pos  pixel.Vec
+	size pixel.Vec
+	typ  byte
+	hits map[Collideable]int
+}
+
+func newTestBox(x, y float64, typ byte) *testBox {
+	return &testBox{pos: pixel.V(x, y), size: pixel.V(16, 16), typ: typ, hits: map[Collideable]int{}}
+}
+
+func (b *testBox) GetPosition() pixel.Vec    { return b.pos }
+func (b *testBox) GetOldPosition() pixel.Vec { return b.pos }
+func (b *testBox) GetSize() pixel.Vec        { return b.size }
+func (b *testBox) Collide(c Collideable)     { b.hits[c]++ }
+func (b *testBox) IsSolid() bool             { return true }
+func (b *testBox) GetType() byte             { return b.typ }
+func (b *testBox) DrawDebug(pixel.Target)    {}
+
+func cellHas(h *SpatialHash, cell IntVec, c Collideable) bool {
+	for _, other := range h.Cells[cell] {
+		if other == c {
+			return true
+		}
+	}
+
+	return false
+}
+
+func TestSpatialHashSpansCellBorders(t *testing.T) {
+	h := NewSpatialHash(64)
+	b := newTestBox(56, 56, CollideableTypeBlock)
+	h.Insert(b)
+
+	for _, cell := range []IntVec{{0, 0}, {1, 0}, {0, 1}, {1, 1}} {
+		if !cellHas(h, cell, b) {
+			t.Fatalf("a box over the corner of four cells isn't in cell %v", cell)
+		}
+	}
+	if len(h.Cells) != 4 {
+		t.Fatalf("the box is in %d cells, want 4", len(h.Cells))
+	}
+
+	// it's found from any of its cells but only once from all of them
+	if got := h.Query(pixel.V(100, 100), pixel.V(1, 1)); len(got) != 1 || got[0] != b {
+		t.Fatalf("querying the top right cell found %v", got)
+	}
+	if got := h.Query(pixel.V(0, 0), pixel.V(127, 127)); len(got) != 1 {
+		t.Fatalf("querying all four cells found the box %d times", len(got))
+	}
+	if got := h.Query(pixel.V(200, 200), pixel.V(1, 1)); len(got) != 0 {
+		t.Fatalf("querying a cell the box isn't in found %v", got)
+	}
+}
+
+func TestSpatialHashUpdateMovesAcrossCells(t *testing.T) {
+	h := NewSpatialHash(64)
+	b := newTestBox(10, 10, CollideableTypePlayer)
+	h.Insert(b)
+
+	b.pos = pixel.V(200, 10)
+	h.Update(b)
+
+	if cellHas(h, NewIntVec(0, 0), b) {
+		t.Fatal("the box is still in the cell it moved out of")
+	}
+	if !cellHas(h, NewIntVec(3, 0), b) {
+		t.Fatal("the box isn't in the cell it moved into")
+	}
+	if len(h.Cells) != 1 {
+		t.Fatalf("there are %d cells after moving, want 1", len(h.Cells))
+	}
+
+	// moving within a cell keeps it where it is
+	b.pos = pixel.V(210, 20)
+	h.Update(b)
+	if !cellHas(h, NewIntVec(3, 0), b) || len(h.Cells) != 1 {
+		t.Fatal("moving inside a cell changed the cells the box is in")
+	}
+}
+
+func TestSpatialHashCheckCollisionsUpdatesMovers(t *testing.T) {
+	h := NewSpatialHash(64)
+	mover := newTestBox(10, 10, CollideableTypePlayer)
+	block := newTestBox(300, 10, CollideableTypeBlock)
+	h.Insert(mover)
+	h.Insert(block)
+
+	// moved without calling Update, CheckCollisions has to find it in its new cell
+	mover.pos = pixel.V(295, 10)
+	h.CheckCollisions()
+
+	if mover.hits[block] != 1 || block.hits[mover] != 1 {
+		t.Fatalf("a box that moved onto a block collided %d and %d times, want once each way", mover.hits[block], block.hits[mover])
+	}
+}
+
+func TestSpatialHashRemoveLeavesNoCells(t *testing.T) {
+	h := NewSpatialHash(64)
+	block := newTestBox(56, 56, CollideableTypeBlock)
+	mover := newTestBox(120, 120, CollideableTypePlayer)
+	h.Insert(block)
+	h.Insert(mover)
+
+	mover.pos = pixel.V(-100, -100)
+	h.Update(mover)
+
+	h.Remove(block)
+	h.Remove(mover)
+
+	if len(h.Cells) != 0 || len(h.Entries) != 0 || len(h.Dynamic) != 0 || h.Len() != 0 {
+		t.Fatalf("removing everything left %d cells, %d entries and %d moving", len(h.Cells), len(h.Entries), len(h.Dynamic))
+	}
+
+	// removing twice does nothing
+	h.Remove(block)
+	if h.Len() != 0 {
+		t.Fatal("removing a box twice changed the hash")
+	}
+}
+
+func TestSpatialHashReportsEveryPairOnce(t *testing.T) {
+	h := NewSpatialHash(64)
+
+	// everything overlaps the first player and spans a cell border so it's found in more than one cell
+	player := newTestBox(58, 58, CollideableTypePlayer)
+	other := newTestBox(60, 50, CollideableTypePlayer)
+	floater := newTestBox(50, 60, CollideableTypeFloater)
+	tree := newTestBox(52, 52, CollideableTypeBlock)
+	rock := newTestBox(62, 62, CollideableTypeBlock)
+
+	for _, c := range []*testBox{player, other, floater, tree, rock} {
+		h.Insert(c)
+	}
+	h.CheckCollisions()
+
+	pairs := [][2]*testBox{
+		{player, other}, {player, floater}, {player, tree}, {player, rock},
+		{other, floater}, {other, tree}, {other, rock},
+		{floater, tree}, {floater, rock},
+	}
+	for _, p := range pairs {
+		if p[0].hits[p[1]] != 1 || p[1].hits[p[0]] != 1 {
+			t.Errorf("a pair collided %d and %d times, want once each way", p[0].hits[p[1]], p[1].hits[p[0]])
+		}
+	}
+
+	// blocks never move so they're never tested against each other, even these two that overlap
+	if tree.hits[rock] != 0 || rock.hits[tree] != 0 {
+		t.Error("two blocks were tested against each other")
+	}
+}
+
+// newCollisionScene makes trees on count random tiles with movers scattered between them, like a forest
+func newCollisionScene(count, movers int) ([]*testBox, []*testBox) {
+	rnd := rand.New(rand.NewPCG(1, 2))
+	side := 100
+	used := map[IntVec]bool{}
+
+	trees := []*testBox{}
+	for len(trees) < count {
+		tile := NewIntVec(rnd.IntN(side), rnd.IntN(side))
+		if used[tile] {
+			continue
+		}
+		used[tile] = true
+		trees = append(trees, newTestBox(float64(tile.X*16), float64(tile.Y*16), CollideableTypeBlock))
+	}
+
+	moving := []*testBox{}
+	for i := 0; i < movers; i++ {
+		moving = append(moving, newTestBox(rnd.Float64()*float64(side*16), rnd.Float64()*float64(side*16), CollideableTypeFloater))
+	}
+
+	return trees, moving
+}
+
+// bruteForceCollisions is how collisions were checked before the spatial hash, every collideable against every other
+func bruteForceCollisions(all []Collideable) {
+	for i, first := range all {
+		for _, second := range all[i+1:] {
+			if !first.IsSolid() || !second.IsSolid() {
+				continue
+			}
+
+			if CollisionBBox(first.GetPosition(), first.GetSize(), second.GetPosition(), second.GetSize()) {
+				first.Collide(second)
+				second.Collide(first)
+			}
+		}
+	}
+}
+
+func TestSpatialHashMatchesBruteForce(t *testing.T) {
+	trees, movers := newCollisionScene(2000, 200)
+
+	h := NewSpatialHash(CollisionCellSize)
+	all := []Collideable{}
+	for _, b := range append(trees, movers...) {
+		h.Insert(b)
+		all = append(all, b)
+	}
+
+	h.CheckCollisions()
+	hashed := map[[2]Collideable]int{}
+	for _, b := range append(trees, movers...) {
+		for c, n := range b.hits {
+			hashed[[2]Collideable{b, c}] = n
+		}
+		b.hits = map[Collideable]int{}
+	}
+
+	bruteForceCollisions(all)
+	total := 0
+	for _, b := range append(trees, movers...) {
+		for c, n := range b.hits {
+			total += n
+			if hashed[[2]Collideable{b, c}] != n {
+				t.Fatalf("brute force collided a pair %d times but the spatial hash did %d times", n, hashed[[2]Collideable{b, c}])
+			}
+		}
+	}
+
+	if total != len(hashed) || total == 0 {
+		t.Fatalf("brute force found %d collisions and the spatial hash found %d", total, len(hashed))
+	}
+}
+
+const (
+	benchmarkTrees  = 5000
+	benchmarkMovers = 50
+)
+
+func BenchmarkCheckCollisions_SpatialHash(b *testing.B) {
+	trees, movers := newCollisionScene(benchmarkTrees, benchmarkMovers)
+
+	h := NewSpatialHash(CollisionCellSize)
+	for _, c := range append(trees, movers...) {
+		h.Insert(c)
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		h.CheckCollisions()
+	}
+}
+
+func BenchmarkCheckCollisions_BruteForce(b *testing.B) {
+	trees, movers := newCollisionScene(benchmarkTrees, benchmarkMovers)
+
+	all := []Collideable{}
+	for _, c := range append(trees, movers...) {
+		all = append(all, c)
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		bruteForceCollisions(all)
+	}
+}
@@ -0,0 +1,298 @@
+package game
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"slices"
+	"unicode/utf8"
+)
+
+const (
+	StructureDefinitionsPath = "structures.json" // in AssetsDirectory
+
+	StructureRegionSize     = 64 // at most one structure is planned for every square of this many tiles
+	StructureSpawnClearance = 16 // tiles around the middle of the world that structures stay out of
+
+	// mixed into the seed so structures don't roll the same numbers as everything else planned per region
+	structureSalt     uint64 = 0xc2b2ae3d27d4eb4f
+	structureTileSalt uint64 = 0x165667b19e3779f9
+)
+
+// StructureDefinitions is everything that can be generated, they're loaded from StructureDefinitionsPath at startup
+type StructureDefinitions struct {
+	LootTables map[string]*LootTable  `json:"loot_tables"`
+	Structures []*StructureDefinition `json:"structures"`
+}
+
+// StructureDefinition is a prefab that's put on top of the generated ground, its layout is a grid of characters that
+// are looked up in its legend. Characters that aren't in the legend leave the tile as it was generated.
+type StructureDefinition struct {
+	Name   string                    `json:"name"`
+	Biomes []string                  `json:"biomes"` // by name, it's only generated where its middle is in one of them
+	Chance float64                   `json:"chance"` // how likely a region is to have one, the chances of every structure are stacked
+	Legend map[string]*StructureTile `json:"legend"`
+	Layout []string                  `json:"layout"` // rows from the top down like it looks in the file
+
+	BiomeIDs []byte          `json:"-"`
+	W        int             `json:"-"`
+	H        int             `json:"-"`
+	Tiles    []StructurePart `json:"-"`
+}
+
+// StructureTile is what a character in a layout turns into, everything on top of the ground is cleared first even if
+// nothing is put back so trees and ore don't grow through it
+type StructureTile struct {
+	Floor  string  `json:"floor"`  // put on the ground, like wood planks
+	Block  string  `json:"block"`  // put on the floor, like a wall or a chest
+	Frame  byte    `json:"frame"`  // of the block
+	Chance float64 `json:"chance"` // how likely the block is to be there at all so ruins look broken, always if not set
+	Loot   string  `json:"loot"`   // the loot table the blocks container is filled from
+
+	FloorType byte       `json:"-"`
+	HasFloor  bool       `json:"-"`
+	BlockType byte       `json:"-"`
+	HasBlock  bool       `json:"-"`
+	LootTable *LootTable `json:"-"`
+}
+
+// StructurePart is one tile of a structures layout, y = 0 is the bottom row like the rest of the map
+type StructurePart struct {
+	X    int
+	Y    int
+	Tile *StructureTile
+}
+
+// PlannedStructure is a structure and the map block coordinates of its bottom left corner
+type PlannedStructure struct {
+	Def *StructureDefinition
+	X   int
+	Y   int
+}
+
+var Structures []*StructureDefinition
+
+// LoadStructureDefinitions reads every structure and loot table, blocks, items and biomes are referred to by name so
+// it has to be called once they're all loaded
+func LoadStructureDefinitions(path string) error {
+	defs := StructureDefinitions{}
+	if err := readJSON(path, &defs); err != nil {
+		return err
+	}
+
+	for name, t := range defs.LootTables {
+		if t.Rolls[1] < t.Rolls[0] {
+			return fmt.Errorf("%s: loot table %q rolls at most fewer times than at least", path, name)
+		}
+
+		for _, e := range t.Entries {
+			def := GetItemDefinitionByName(e.Item)
+			if def == nil {
+				return fmt.Errorf("%s: loot table %q has unknown item %q", path, name, e.Item)
+			}
+
+			e.Key = ItemKey{UnderlyingType: def.ID.UnderlyingType, ItemType: def.ID.ItemType, Frame: e.Frame}
+			if e.Min <= 0 {
+				e.Min = 1
+			}
+			if e.Max < e.Min {
+				e.Max = e.Min
+			}
+			if e.Weight <= 0 {
+				e.Weight = 1
+			}
+		}
+	}
+
+	for _, s := range defs.Structures {
+		if err := s.resolve(defs.LootTables); err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+	}
+
+	Structures = defs.Structures
+
+	return nil
+}
+
+// resolve looks up everything s refers to by name and turns its layout into parts
+func (s *StructureDefinition) resolve(lootTables map[string]*LootTable) error {
+	s.BiomeIDs = []byte{}
+	for _, name := range s.Biomes {
+		id, ok := GetBiomeIDByName(name)
+		if !ok {
+			return fmt.Errorf("structure %q is in unknown biome %q", s.Name, name)
+		}
+		s.BiomeIDs = append(s.BiomeIDs, id)
+	}
+
+	for char, t := range s.Legend {
+		if utf8.RuneCountInString(char) != 1 {
+			return fmt.Errorf("structure %q has legend entry %q that isn't one character", s.Name, char)
+		}
+
+		if t.Floor != "" {
+			if t.FloorType, t.HasFloor = GetBlockTypeByName(t.Floor); !t.HasFloor {
+				return fmt.Errorf("structure %q has unknown floor %q", s.Name, t.Floor)
+			}
+		}
+
+		if t.Block != "" {
+			if t.BlockType, t.HasBlock = GetBlockTypeByName(t.Block); !t.HasBlock {
+				return fmt.Errorf("structure %q has unknown block %q", s.Name, t.Block)
+			}
+		}
+
+		if t.Loot != "" {
+			if t.LootTable = lootTables[t.Loot]; t.LootTable == nil {
+				return fmt.Errorf("structure %q has unknown loot table %q", s.Name, t.Loot)
+			}
+		}
+
+		if t.Chance <= 0 {
+			t.Chance = 1
+		}
+	}
+
+	s.H = len(s.Layout)
+	s.W = 0
+	s.Tiles = []StructurePart{}
+
+	for row, line := range s.Layout {
+		chars := []rune(line)
+		if row == 0 {
+			s.W = len(chars)
+		} else if len(chars) != s.W {
+			return fmt.Errorf("structure %q has rows of different lengths", s.Name)
+		}
+
+		for x, char := range chars {
+			t, ok := s.Legend[string(char)]
+			if !ok {
+				continue
+			}
+
+			s.Tiles = append(s.Tiles, StructurePart{X: x, Y: s.H - 1 - row, Tile: t})
+		}
+	}
+
+	if s.W == 0 || s.H == 0 || s.W > StructureRegionSize || s.H > StructureRegionSize {
+		return fmt.Errorf("structure %q has to be between 1 and %d tiles across", s.Name, StructureRegionSize)
+	}
+
+	return nil
+}
+
+// PlaceStructures builds every structure that reaches into c, biomeAt is the id of the biome at every map block
+// coordinate. Structures are planned per region from the seed alone and always fit inside their region, so one that
+// crosses a chunk border is finished the same way in the next chunk no matter which one is generated first.
+func PlaceStructures(c *Chunk, seed uint64, biomeAt func(x, y int) byte) {
+	minX := c.X * c.W
+	minY := c.Y * c.H
+	maxX := minX + c.W - 1
+	maxY := minY + c.H - 1
+
+	for ry := floorDiv(minY, StructureRegionSize); ry <= floorDiv(maxY, StructureRegionSize); ry++ {
+		for rx := floorDiv(minX, StructureRegionSize); rx <= floorDiv(maxX, StructureRegionSize); rx++ {
+			s := PlanStructure(seed, rx, ry, biomeAt)
+			if s == nil {
+				continue
+			}
+
+			for _, part := range s.Def.Tiles {
+				x := s.X + part.X
+				y := s.Y + part.Y
+				if x < minX || x > maxX || y < minY || y > maxY {
+					continue
+				}
+
+				placeStructureTile(c, x-minX, y-minY, part.Tile, seed, x, y)
+			}
+		}
+	}
+}
+
+// PlanStructure returns the structure in region rx, ry, nil if there isn't one
+func PlanStructure(seed uint64, rx, ry int, biomeAt func(x, y int) byte) *PlannedStructure {
+	rnd := rand.New(rand.NewPCG(seed^structureSalt, mixChunkCoords(rx, ry)))
+
+	// the chances are stacked so only one roll is needed
+	roll := rnd.Float64()
+	var def *StructureDefinition
+	for _, s := range Structures {
+		if roll < s.Chance {
+			def = s
+			break
+		}
+		roll -= s.Chance
+	}
+	if def == nil {
+		return nil
+	}
+
+	s := &PlannedStructure{
+		Def: def,
+		X:   rx*StructureRegionSize + rnd.IntN(StructureRegionSize-def.W+1),
+		Y:   ry*StructureRegionSize + rnd.IntN(StructureRegionSize-def.H+1),
+	}
+
+	if s.X-StructureSpawnClearance < 0 && s.X+def.W+StructureSpawnClearance > 0 &&
+		s.Y-StructureSpawnClearance < 0 && s.Y+def.H+StructureSpawnClearance > 0 {
+		return nil
+	}
+
+	if !slices.Contains(def.BiomeIDs, biomeAt(s.X+def.W/2, s.Y+def.H/2)) {
+		return nil
+	}
+
+	// nothing is built on water
+	for _, part := range def.Tiles {
+		b := Biomes[biomeAt(s.X+part.X, s.Y+part.Y)]
+		if b == nil || GetBlockDefinition(b.Ground).Liquid {
+			return nil
+		}
+	}
+
+	return s
+}
+
+// placeStructureTile clears the tile at tx, ty in c down to the ground and builds t on it, x, y are its map block
+// coordinates so what's rolled for it doesn't depend on which chunk it's in
+func placeStructureTile(c *Chunk, tx, ty int, t *StructureTile, seed uint64, x, y int) {
+	stack := c.Blocks[ty][tx]
+	if len(stack) == 0 {
+		return
+	}
+
+	for _, b := range stack[1:] {
+		if b.IsSolid() {
+			RemoveCollideable(b)
+		}
+	}
+	stack = stack[:1]
+
+	pos := stack[0].Position
+	rnd := rand.New(rand.NewPCG(seed^structureTileSalt, mixChunkCoords(x, y)))
+
+	built := []*Block{}
+	if t.HasFloor {
+		built = append(built, NewBlock(t.FloorType, 0, pos))
+	}
+	if t.HasBlock && rnd.Float64() < t.Chance {
+		built = append(built, NewBlock(t.BlockType, t.Frame, pos))
+	}
+
+	for _, b := range built {
+		stack = append(stack, b)
+		c.AddEntityBlock(b)
+
+		if b.IsSolid() {
+			AddCollideable(b)
+		}
+
+		if container, ok := b.Entity.(Container); ok && t.LootTable != nil {
+			t.LootTable.Fill(container, rnd)
+		}
+	}
+
+	c.Blocks[ty][tx] = stack
+}
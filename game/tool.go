@@ -0,0 +1,186 @@
+package game
+
+import "github.com/gopxl/pixel/v2"
+
+const ToolsPath = "tools.png" // in AssetsDirectory
+
+// what a tool is, blocks name the kind of tool that breaks them faster in BlockDefinitionsPath
+const (
+	ToolKindPickaxe byte = 0
+	ToolKindAxe     byte = 1
+	ToolKindShovel  byte = 2
+	ToolKindSword   byte = 3
+)
+
+var ToolKindNames = map[string]byte{
+	"pickaxe": ToolKindPickaxe,
+	"axe":     ToolKindAxe,
+	"shovel":  ToolKindShovel,
+	"sword":   ToolKindSword,
+}
+
+const (
+	ToolTierWood   byte = 0
+	ToolTierStone  byte = 1
+	ToolTierCopper byte = 2
+)
+
+const (
+	ToolTypeWoodPickaxe   byte = 0
+	ToolTypeWoodAxe       byte = 1
+	ToolTypeWoodShovel    byte = 2
+	ToolTypeWoodSword     byte = 3
+	ToolTypeStonePickaxe  byte = 4
+	ToolTypeStoneAxe      byte = 5
+	ToolTypeStoneShovel   byte = 6
+	ToolTypeStoneSword    byte = 7
+	ToolTypeCopperPickaxe byte = 8
+	ToolTypeCopperAxe     byte = 9
+	ToolTypeCopperShovel  byte = 10
+	ToolTypeCopperSword   byte = 11
+)
+
+// Tool is an item that breaks some blocks faster and wears out while it's used
+type Tool struct {
+	Name       string
+	Kind       byte
+	Tier       byte
+	Frame      [4]float64 // x, y, w, h in the tools sheet measured from the top left
+	Durability int        // how many hits it lasts
+	Speed      float64    // how many hits a hit is worth on blocks broken by this kind of tool
+	Sprite     *pixel.Sprite
+}
+
+// the tools sheet has a row for every kind of tool and a column for every tier
+var Tools = map[byte]*Tool{
+	ToolTypeWoodPickaxe: {
+		Name:       "wood pickaxe",
+		Kind:       ToolKindPickaxe,
+		Tier:       ToolTierWood,
+		Frame:      [4]float64{0, 0, 16, 16},
+		Durability: 60,
+		Speed:      2,
+	},
+	ToolTypeWoodAxe: {
+		Name:       "wood axe",
+		Kind:       ToolKindAxe,
+		Tier:       ToolTierWood,
+		Frame:      [4]float64{0, 16, 16, 16},
+		Durability: 60,
+		Speed:      2,
+	},
+	ToolTypeWoodShovel: {
+		Name:       "wood shovel",
+		Kind:       ToolKindShovel,
+		Tier:       ToolTierWood,
+		Frame:      [4]float64{0, 32, 16, 16},
+		Durability: 60,
+		Speed:      2,
+	},
+	ToolTypeWoodSword: {
+		Name:       "wood sword",
+		Kind:       ToolKindSword,
+		Tier:       ToolTierWood,
+		Frame:      [4]float64{0, 48, 16, 16},
+		Durability: 60,
+		Speed:      2,
+	},
+	ToolTypeStonePickaxe: {
+		Name:       "stone pickaxe",
+		Kind:       ToolKindPickaxe,
+		Tier:       ToolTierStone,
+		Frame:      [4]float64{16, 0, 16, 16},
+		Durability: 130,
+		Speed:      3,
+	},
+	ToolTypeStoneAxe: {
+		Name:       "stone axe",
+		Kind:       ToolKindAxe,
+		Tier:       ToolTierStone,
+		Frame:      [4]float64{16, 16, 16, 16},
+		Durability: 130,
+		Speed:      3,
+	},
+	ToolTypeStoneShovel: {
+		Name:       "stone shovel",
+		Kind:       ToolKindShovel,
+		Tier:       ToolTierStone,
+		Frame:      [4]float64{16, 32, 16, 16},
+		Durability: 130,
+		Speed:      3,
+	},
+	ToolTypeStoneSword: {
+		Name:       "stone sword",
+		Kind:       ToolKindSword,
+		Tier:       ToolTierStone,
+		Frame:      [4]float64{16, 48, 16, 16},
+		Durability: 130,
+		Speed:      3,
+	},
+	ToolTypeCopperPickaxe: {
+		Name:       "copper pickaxe",
+		Kind:       ToolKindPickaxe,
+		Tier:       ToolTierCopper,
+		Frame:      [4]float64{64, 0, 16, 16},
+		Durability: 250,
+		Speed:      4,
+	},
+	ToolTypeCopperAxe: {
+		Name:       "copper axe",
+		Kind:       ToolKindAxe,
+		Tier:       ToolTierCopper,
+		Frame:      [4]float64{64, 16, 16, 16},
+		Durability: 250,
+		Speed:      4,
+	},
+	ToolTypeCopperShovel: {
+		Name:       "copper shovel",
+		Kind:       ToolKindShovel,
+		Tier:       ToolTierCopper,
+		Frame:      [4]float64{64, 32, 16, 16},
+		Durability: 250,
+		Speed:      4,
+	},
+	ToolTypeCopperSword: {
+		Name:       "copper sword",
+		Kind:       ToolKindSword,
+		Tier:       ToolTierCopper,
+		Frame:      [4]float64{64, 48, 16, 16},
+		Durability: 250,
+		Speed:      4,
+	},
+}
+
+// LoadToolSprites cuts the sprite of every tool out of the tools sheet at path
+func LoadToolSprites(path string) error {
+	s, err := NewSpritesheet(path)
+	if err != nil {
+		return err
+	}
+
+	h := s.Picture.Bounds().H()
+
+	for _, t := range Tools {
+		f := t.Frame
+		t.Sprite = pixel.NewSprite(s.Picture, pixel.R(f[0], h-f[1], f[0]+f[2], h-f[1]-f[3]))
+	}
+
+	return nil
+}
+
+func GetTool(itemType byte) *Tool {
+	return Tools[itemType]
+}
+
+// MiningSpeed returns how many hits a hit with the tool is worth on a block, a nil tool is a bare hand
+func (t *Tool) MiningSpeed(def *BlockDefinition) float64 {
+	if t == nil || def == nil || def.Tool == "" {
+		return 1
+	}
+
+	if ToolKindNames[def.Tool] != t.Kind {
+		return 1
+	}
+
+	return t.Speed
+}
@@ -0,0 +1,436 @@
+package game
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/gopxl/pixel/v2"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// WorldsDirectory is where every world is saved, tests point it at a temporary directory
+var WorldsDirectory = "./worlds"
+
+const (
+	WorldFileName    = "world.json"
+	ChunksDirectory  = "chunks"
+	PlayersDirectory = "players"
+)
+
+// WorldData is everything in a world that isn't stored in one of its chunk files
+type WorldData struct {
+	Name      string
+	Seed      uint64
+	Generator string // the name of the world generator, see WorldGenerators
+	Spawn     pixel.Vec
+	Player    PlayerData
+	Floaters  []FloaterData
+}
+
+type PlayerData struct {
+	Position   pixel.Vec
+	HotbarX    int
+	Inventory  []InventoryItemData
+	Equipment  []InventoryItemData // X is the equipment slot
+	Health     float64
+	Hunger     float64
+	Thirst     float64
+	Dead       bool
+	SpawnBlock *IntVec
+}
+
+type InventoryItemData struct {
+	UnderlyingType byte
+	ItemType       byte
+	Frame          byte
+	Amount         int
+	Meta           ItemMeta
+	X              int
+	Y              int
+}
+
+type FloaterData struct {
+	UnderlyingType byte
+	ItemType       byte
+	Frame          byte
+	Amount         int
+	Meta           ItemMeta
+	Position       pixel.Vec
+	Velocity       pixel.Vec
+}
+
+type ChunkData struct {
+	X      int
+	Y      int
+	W      int
+	H      int
+	Blocks [][][]BlockData // [y][x] stack of blocks from the bottom up
+	Ticks  []ScheduledTick `json:",omitempty"`
+}
+
+type BlockData struct {
+	Type   byte
+	Frame  byte
+	Entity *BlockEntityData `json:",omitempty"`
+}
+
+func WorldPath(name string) string {
+	return filepath.Join(WorldsDirectory, name)
+}
+
+func (m *Map) Path() string {
+	return WorldPath(m.Name)
+}
+
+func (m *Map) ChunkPath(x, y int) string {
+	return filepath.Join(m.Path(), ChunksDirectory, fmt.Sprintf("%d_%d.json", x, y))
+}
+
+// LoadWorldData reads the world file for the world called name, it returns nil if the world hasn't been saved yet
+func LoadWorldData(name string) (*WorldData, error) {
+	data := &WorldData{}
+
+	err := readJSON(filepath.Join(WorldPath(name), WorldFileName), data)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
+
+func SaveWorldData(data WorldData) error {
+	return writeJSON(filepath.Join(WorldPath(data.Name), WorldFileName), data)
+}
+
+// Save writes the world file and every loaded chunk to the worlds directory
+func (w *World) Save() error {
+	data := WorldData{
+		Name:      w.Map.Name,
+		Seed:      w.Map.Seed,
+		Generator: w.Map.Generator.Name(),
+		Spawn:     w.Map.Spawn,
+		Player:    w.Player.ToData(),
+		Floaters:  FloatersToData(),
+	}
+
+	if err := SaveWorldData(data); err != nil {
+		return err
+	}
+
+	return w.Map.Save()
+}
+
+// FloatersToData returns the save data of every floater that hasn't been picked up
+func FloatersToData() []FloaterData {
+	data := []FloaterData{}
+
+	for _, f := range Floaters {
+		if !f.Deleted {
+			data = append(data, f.ToData())
+		}
+	}
+
+	return data
+}
+
+// LoadFloaters adds the saved floaters back into the world
+func LoadFloaters(data []FloaterData) {
+	for _, fd := range data {
+		f := SpawnFloater(fd.UnderlyingType, fd.ItemType, fd.Frame, fd.Position, fd.Velocity)
+
+		// floaters saved before they could hold more than one item have no amount
+		if fd.Amount > 0 {
+			f.Amount = fd.Amount
+		}
+		f.Meta = loadedMeta(fd.UnderlyingType, fd.ItemType, fd.Meta)
+	}
+}
+
+// Load restores the player and floaters from a saved world
+func (w *World) Load(data *WorldData) {
+	w.Map.Spawn = data.Spawn
+	w.Player.LoadData(data.Player)
+	LoadFloaters(data.Floaters)
+}
+
+func (m *Map) PlayerPath(name string) string {
+	return filepath.Join(m.Path(), PlayersDirectory, name+".json")
+}
+
+// SavePlayer stores a players data by name, it's used by the server where more than one player shares a world
+func (m *Map) SavePlayer(name string, data PlayerData) error {
+	return writeJSON(m.PlayerPath(name), data)
+}
+
+// LoadPlayer reads a players data by name, it returns nil if the player has never joined this world
+func (m *Map) LoadPlayer(name string) (*PlayerData, error) {
+	data := &PlayerData{}
+
+	err := readJSON(m.PlayerPath(name), data)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
+
+func (m *Map) Save() error {
+	for _, row := range m.Chunks {
+		for _, c := range row {
+			if err := m.SaveChunk(c); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (m *Map) SaveChunk(c *Chunk) error {
+	return writeJSON(m.ChunkPath(c.X, c.Y), c.ToData())
+}
+
+// MoveChunkAside renames the saved chunk at x, y so it isn't overwritten when the chunk is generated again, it's used
+// when the saved chunk can't be read
+func (m *Map) MoveChunkAside(x, y int) error {
+	path := m.ChunkPath(x, y)
+	return os.Rename(path, fmt.Sprintf("%s.corrupt-%d", path, time.Now().UnixNano()))
+}
+
+// LoadChunk reads a chunk from disk, it returns nil if the chunk has never been saved
+func (m *Map) LoadChunk(x, y int) (*Chunk, error) {
+	data := ChunkData{}
+
+	err := readJSON(m.ChunkPath(x, y), &data)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return NewChunkFromData(data), nil
+}
+
+func (c *Chunk) ToData() ChunkData {
+	data := ChunkData{
+		X:      c.X,
+		Y:      c.Y,
+		W:      c.W,
+		H:      c.H,
+		Blocks: [][][]BlockData{},
+	}
+
+	for ty := 0; ty < c.H; ty++ {
+		row := [][]BlockData{}
+		for tx := 0; tx < c.W; tx++ {
+			stack := []BlockData{}
+			for _, b := range c.Blocks[ty][tx] {
+				bd := BlockData{Type: b.Type, Frame: b.Frame}
+				if b.Entity != nil {
+					entity := b.Entity.ToData()
+					bd.Entity = &entity
+				}
+				stack = append(stack, bd)
+			}
+			row = append(row, stack)
+		}
+		data.Blocks = append(data.Blocks, row)
+	}
+
+	for _, t := range c.Ticks {
+		data.Ticks = append(data.Ticks, *t)
+	}
+
+	return data
+}
+
+func NewChunkFromData(data ChunkData) *Chunk {
+	newChunk := &Chunk{
+		X:      data.X,
+		Y:      data.Y,
+		W:      data.W,
+		H:      data.H,
+		Blocks: map[int]map[int][]*Block{},
+		Dirty:  true,
+	}
+
+	for ty, row := range data.Blocks {
+		newChunk.Blocks[ty] = map[int][]*Block{}
+		for tx, stack := range row {
+			pos := pixel.V(float64(data.X)*256+float64(tx)*16, float64(data.Y)*256+float64(ty)*16)
+
+			for _, bd := range stack {
+				b := NewBlock(bd.Type, bd.Frame, pos)
+				if b.Entity != nil && bd.Entity != nil {
+					b.Entity.LoadData(*bd.Entity)
+				}
+				newChunk.Blocks[ty][tx] = append(newChunk.Blocks[ty][tx], b)
+				newChunk.AddEntityBlock(b)
+
+				if b.IsSolid() {
+					AddCollideable(b)
+				}
+			}
+		}
+	}
+
+	for _, t := range data.Ticks {
+		newChunk.Ticks = append(newChunk.Ticks, &t)
+	}
+
+	return newChunk
+}
+
+func (p *Player) ToData() PlayerData {
+	data := PlayerData{
+		Position:   p.Position,
+		HotbarX:    p.HotbarX,
+		Inventory:  []InventoryItemData{},
+		Equipment:  []InventoryItemData{},
+		Health:     p.Health,
+		Hunger:     p.Hunger,
+		Thirst:     p.Thirst,
+		Dead:       p.Dead,
+		SpawnBlock: p.SpawnBlock,
+	}
+
+	for y := 0; y < len(p.Inventory); y++ {
+		for x := 0; x < len(p.Inventory[y]); x++ {
+			item := p.Inventory[y][x]
+			if item == nil {
+				continue
+			}
+
+			data.Inventory = append(data.Inventory, InventoryItemData{
+				UnderlyingType: item.UnderlyingType,
+				ItemType:       item.ItemType,
+				Frame:          item.Frame,
+				Amount:         item.Amount,
+				Meta:           item.Meta,
+				X:              x,
+				Y:              y,
+			})
+		}
+	}
+
+	for slot, item := range p.Equipment {
+		if item == nil {
+			continue
+		}
+
+		data.Equipment = append(data.Equipment, InventoryItemData{
+			UnderlyingType: item.UnderlyingType,
+			ItemType:       item.ItemType,
+			Frame:          item.Frame,
+			Amount:         item.Amount,
+			Meta:           item.Meta,
+			X:              slot,
+		})
+	}
+
+	return data
+}
+
+func (p *Player) LoadData(data PlayerData) {
+	p.Position = data.Position
+	p.OldPosition = data.Position
+	p.HotbarX = data.HotbarX
+	p.Dead = data.Dead
+	p.SpawnBlock = data.SpawnBlock
+
+	// players saved before stats existed have none so they keep the full stats from NewPlayer
+	if data.Health > 0 {
+		p.Health = data.Health
+		p.Hunger = data.Hunger
+		p.Thirst = data.Thirst
+	}
+
+	p.ClearInventory()
+	for _, i := range data.Inventory {
+		if i.Y < 0 || i.Y >= len(p.Inventory) || i.X < 0 || i.X >= len(p.Inventory[i.Y]) {
+			continue
+		}
+
+		item := NewInventoryItem(i.UnderlyingType, i.ItemType, i.Frame, i.Amount, pixel.V(float64(i.X), float64(i.Y)))
+		item.Meta = loadedMeta(i.UnderlyingType, i.ItemType, i.Meta)
+		p.AddInventoryItem(item)
+	}
+
+	p.ClearEquipment()
+	for _, i := range data.Equipment {
+		if i.X < 0 || i.X >= len(p.Equipment) {
+			continue
+		}
+
+		item := NewInventoryItem(i.UnderlyingType, i.ItemType, i.Frame, i.Amount, pixel.ZV)
+		item.Meta = loadedMeta(i.UnderlyingType, i.ItemType, i.Meta)
+		p.Equip(item, byte(i.X))
+	}
+}
+
+func (f *Floater) ToData() FloaterData {
+	return FloaterData{
+		UnderlyingType: f.UnderlyingType,
+		ItemType:       f.ItemType,
+		Frame:          f.Frame,
+		Amount:         f.Amount,
+		Meta:           f.Meta,
+		Position:       f.Position,
+		Velocity:       f.Velocity,
+	}
+}
+
+func readJSON(path string, v any) error {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(b, v)
+}
+
+// writeJSON writes v to a temporary file next to path and renames it over path once it's all written, so a crash
+// part way through leaves the old file instead of half of the new one
+func writeJSON(path string, v any) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name()) // does nothing once it has been renamed
+
+	if _, err := f.Write(b); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(f.Name(), 0644); err != nil {
+		return err
+	}
+
+	return os.Rename(f.Name(), path)
+}
@@ -0,0 +1,107 @@
+package game
+
+import (
+	"github.com/gopxl/pixel/v2"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestWriteJSONLeavesNoTemporaryFiles(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "nested", "data.json")
+
+	for _, v := range []string{"first", "second"} {
+		if err := writeJSON(path, v); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got := ""
+	if err := readJSON(path, &got); err != nil {
+		t.Fatal(err)
+	}
+	if got != "second" {
+		t.Fatalf("read back %q, want the second write", got)
+	}
+
+	entries, err := os.ReadDir(filepath.Dir(path))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("found %d files after writing, want only data.json", len(entries))
+	}
+}
+
+func TestCorruptChunkIsMovedAside(t *testing.T) {
+	w := newTestWorld(t, WorldGeneratorFlat)
+	m := w.Map
+
+	path := m.ChunkPath(5, 5)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(`{"X": 5, "Blocks": [[[{"Ty`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if c := m.LoadOrGenerateChunk(5, 5); c == nil {
+		t.Fatal("the chunk wasn't generated again")
+	}
+
+	entries, err := os.ReadDir(filepath.Dir(path))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, e := range entries {
+		if strings.HasPrefix(e.Name(), "5_5.json.corrupt-") {
+			b, err := os.ReadFile(filepath.Join(filepath.Dir(path), e.Name()))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !strings.HasPrefix(string(b), `{"X": 5`) {
+				t.Fatalf("the moved chunk file was changed to %q", b)
+			}
+			return
+		}
+	}
+
+	t.Fatal("the corrupt chunk file wasn't kept")
+}
+
+func TestPlayerDataKeepsItemMeta(t *testing.T) {
+	w := newTestWorld(t, WorldGeneratorFlat)
+	p := w.Player
+
+	tool := NewInventoryItem(UnderlyingTypeTool, ToolTypeWoodPickaxe, 0, 1, pixel.V(2, 1))
+	tool.Meta = ItemMeta{Durability: 7, Name: "old faithful"}
+	p.AddInventoryItem(tool)
+
+	helmet := NewInventoryItem(UnderlyingTypeEquipment, EquipmentTypeCopperHelmet, 0, 1, pixel.ZV)
+	helmet.Meta = ItemMeta{Name: "lucky hat", Tags: map[string]string{"dyed": "red"}}
+	p.Equip(helmet, EquipmentSlotHead)
+
+	if err := w.Map.SavePlayer("jesse", p.ToData()); err != nil {
+		t.Fatal(err)
+	}
+	data, err := w.Map.LoadPlayer("jesse")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := NewPlayer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	loaded.LoadData(*data)
+
+	if got := loaded.Inventory[1][2]; got == nil || !got.Meta.Equal(tool.Meta) {
+		t.Fatalf("the tool in the inventory came back as %+v, want meta %+v", got, tool.Meta)
+	}
+	if got := loaded.Equipment[EquipmentSlotHead]; got == nil || !got.Meta.Equal(helmet.Meta) {
+		t.Fatalf("the worn helmet came back as %+v, want meta %+v", got, helmet.Meta)
+	}
+}
@@ -0,0 +1,112 @@
+package game
+
+import "fmt"
+
+const (
+	WorldGeneratorFlat      = "flat"
+	WorldGeneratorGrass     = "grass"
+	WorldGeneratorBiomes    = "biomes"
+	WorldGeneratorSuperflat = "superflat"
+
+	DefaultWorldGenerator = WorldGeneratorBiomes
+)
+
+// WorldGenerator makes the chunks of a world that haven't been saved yet, it has to make the same chunk every time
+// it's asked for the same one
+type WorldGenerator interface {
+	Name() string // what it's saved as with the world
+	GenerateChunk(x, y, w, h int) *Chunk
+}
+
+// every world generator by name and how to make one for a seed
+var WorldGenerators = map[string]func(seed uint64) WorldGenerator{
+	WorldGeneratorFlat:      func(seed uint64) WorldGenerator { return &FlatGenerator{Ground: BlockTypeDirt} },
+	WorldGeneratorGrass:     NewGrassGenerator,
+	WorldGeneratorBiomes:    NewBiomeGenerator,
+	WorldGeneratorSuperflat: func(seed uint64) WorldGenerator { return &SuperflatGenerator{} },
+}
+
+// NewWorldGenerator returns the world generator called name for seed, an empty name is the default
+func NewWorldGenerator(name string, seed uint64) (WorldGenerator, error) {
+	if name == "" {
+		name = DefaultWorldGenerator
+	}
+
+	f, ok := WorldGenerators[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown world generator %q", name)
+	}
+
+	return f(seed), nil
+}
+
+// FlatGenerator covers the world in one block and nothing else, it's meant for testing
+type FlatGenerator struct {
+	Ground byte
+}
+
+func (g *FlatGenerator) Name() string {
+	return WorldGeneratorFlat
+}
+
+func (g *FlatGenerator) GenerateChunk(x, y, w, h int) *Chunk {
+	c := NewEmptyChunk(x, y, w, h)
+
+	for ty := 0; ty < h; ty++ {
+		for tx := 0; tx < w; tx++ {
+			c.Blocks[ty][tx] = []*Block{NewBlock(g.Ground, 0, c.TilePosition(tx, ty))}
+		}
+	}
+
+	return c
+}
+
+// SuperflatGenerator covers the world in grass over dirt with nothing growing on it, so there's room to build
+type SuperflatGenerator struct{}
+
+func (g *SuperflatGenerator) Name() string {
+	return WorldGeneratorSuperflat
+}
+
+func (g *SuperflatGenerator) GenerateChunk(x, y, w, h int) *Chunk {
+	c := NewEmptyChunk(x, y, w, h)
+
+	for ty := 0; ty < h; ty++ {
+		for tx := 0; tx < w; tx++ {
+			pos := c.TilePosition(tx, ty)
+			c.Blocks[ty][tx] = []*Block{
+				NewBlock(BlockTypeDirt, BlockTypeDirtFrameDirt, pos),
+				NewBlock(BlockTypeGrass, BlockTypeGrassFrame1, pos),
+			}
+		}
+	}
+
+	return c
+}
+
+// GrassBiome is the whole world for GrassGenerator, grass with trees, stone, copper and bushes scattered evenly
+var GrassBiome = &Biome{
+	Name:         "grass",
+	Ground:       BlockTypeGrass,
+	TreeChance:   0.021,
+	BushChance:   0.003,
+	StoneChance:  0.002,
+	CopperChance: 0.003,
+}
+
+// GrassGenerator makes the same kind of land everywhere, every tile is rolled for on its own
+type GrassGenerator struct {
+	Seed uint64
+}
+
+func NewGrassGenerator(seed uint64) WorldGenerator {
+	return &GrassGenerator{Seed: seed}
+}
+
+func (g *GrassGenerator) Name() string {
+	return WorldGeneratorGrass
+}
+
+func (g *GrassGenerator) GenerateChunk(x, y, w, h int) *Chunk {
+	return NewChunk(x, y, w, h, g.Seed, func(x, y int) *Biome { return GrassBiome })
+}
@@ -1,10 +1,12 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 	"github.com/gopxl/pixel/v2"
 	"github.com/gopxl/pixel/v2/backends/opengl"
 	"github.com/jessehorne/skafos/game"
+	"github.com/jessehorne/skafos/server"
 	"golang.org/x/image/colornames"
 	"image"
 	"log"
@@ -25,7 +27,7 @@ func loadPicture(path string) (pixel.Picture, error) {
 	return pixel.PictureDataFromImage(img), nil
 }
 
-func run() {
+func run(world, generator string) {
 	cfg := opengl.WindowConfig{
 		Title:  "Skafos v0.0.1",
 		Bounds: pixel.R(0, 0, 900, 600),
@@ -41,17 +43,17 @@ func run() {
 	win.Clear(colornames.Black)
 
 	// create new game
-	g, err := game.NewGame("test", win)
+	g, err := game.NewGame(world, generator, win)
 	if err != nil {
 		log.Fatalln(err)
 	}
 
-	g.Init(win)
+	g.Init()
 	g.Map.RefreshDrawBatch()
 	g.CollideablesDrawDebug = false
 
-	maxFPS := float64(1 / 30)
-	currentFrame := float64(0)
+	// run the simulation at a fixed rate no matter how fast frames are drawn
+	timestep := game.NewFixedTimestep(game.TickDuration, game.MaxCatchUpTicks)
 	last := time.Now()
 
 	win.SetScrollCallback(func(win *opengl.Window, scroll pixel.Vec) {
@@ -70,19 +72,15 @@ func run() {
 	second := time.Tick(time.Second)
 
 	for !win.Closed() {
-		dt := time.Since(last).Seconds()
+		elapsed := time.Since(last).Seconds()
 		last = time.Now()
 
-		currentFrame += dt
+		alpha := timestep.Advance(elapsed, func(dt float64) {
+			g.Update(win, dt)
+		})
 
-		// only draw 30 fps
-		if currentFrame >= maxFPS {
-			win.Clear(colornames.Black)
-			g.Draw()
-			currentFrame = 0
-		}
-
-		g.Update(win, dt)
+		win.Clear(colornames.Black)
+		g.Draw(alpha)
 		win.Update()
 
 		frames++
@@ -93,8 +91,23 @@ func run() {
 		default:
 		}
 	}
+
+	if err := g.Save(); err != nil {
+		log.Println(err)
+	}
 }
 
 func main() {
-	opengl.Run(run)
+	if len(os.Args) > 1 && os.Args[1] == "server" {
+		server.Main(os.Args[2:])
+		return
+	}
+
+	world := flag.String("world", "test", "name of the world to play")
+	generator := flag.String("generator", game.DefaultWorldGenerator, "world generator to create the world with if it doesn't exist yet")
+	flag.Parse()
+
+	opengl.Run(func() {
+		run(*world, *generator)
+	})
 }
@@ -0,0 +1,123 @@
+package protocol
+
+import (
+	"encoding/binary"
+	"io"
+	"math"
+)
+
+type writer struct {
+	buf []byte
+}
+
+func (w *writer) uint8(v byte) {
+	w.buf = append(w.buf, v)
+}
+
+func (w *writer) bool(v bool) {
+	if v {
+		w.uint8(1)
+	} else {
+		w.uint8(0)
+	}
+}
+
+func (w *writer) uint16(v uint16) {
+	w.buf = binary.BigEndian.AppendUint16(w.buf, v)
+}
+
+func (w *writer) uint32(v uint32) {
+	w.buf = binary.BigEndian.AppendUint32(w.buf, v)
+}
+
+func (w *writer) uint64(v uint64) {
+	w.buf = binary.BigEndian.AppendUint64(w.buf, v)
+}
+
+func (w *writer) int32(v int32) {
+	w.uint32(uint32(v))
+}
+
+func (w *writer) float64(v float64) {
+	w.uint64(math.Float64bits(v))
+}
+
+// strings are a uint16 length followed by the bytes
+func (w *writer) string(v string) {
+	if len(v) > math.MaxUint16 {
+		v = v[:math.MaxUint16]
+	}
+	w.uint16(uint16(len(v)))
+	w.buf = append(w.buf, v...)
+}
+
+// reader remembers the first error so messages can decode every field and check once at the end
+type reader struct {
+	buf []byte
+	err error
+}
+
+func (r *reader) next(n int) []byte {
+	if r.err != nil {
+		return nil
+	}
+
+	if len(r.buf) < n {
+		r.err = io.ErrUnexpectedEOF
+		r.buf = nil
+		return nil
+	}
+
+	b := r.buf[:n]
+	r.buf = r.buf[n:]
+	return b
+}
+
+func (r *reader) uint8() byte {
+	b := r.next(1)
+	if b == nil {
+		return 0
+	}
+	return b[0]
+}
+
+func (r *reader) bool() bool {
+	return r.uint8() != 0
+}
+
+func (r *reader) uint16() uint16 {
+	b := r.next(2)
+	if b == nil {
+		return 0
+	}
+	return binary.BigEndian.Uint16(b)
+}
+
+func (r *reader) uint32() uint32 {
+	b := r.next(4)
+	if b == nil {
+		return 0
+	}
+	return binary.BigEndian.Uint32(b)
+}
+
+func (r *reader) uint64() uint64 {
+	b := r.next(8)
+	if b == nil {
+		return 0
+	}
+	return binary.BigEndian.Uint64(b)
+}
+
+func (r *reader) int32() int32 {
+	return int32(r.uint32())
+}
+
+func (r *reader) float64() float64 {
+	return math.Float64frombits(r.uint64())
+}
+
+func (r *reader) string() string {
+	n := r.uint16()
+	return string(r.next(int(n)))
+}
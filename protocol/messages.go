@@ -0,0 +1,343 @@
+package protocol
+
+const (
+	EntityKindPlayer  byte = 0
+	EntityKindFloater byte = 1
+)
+
+// Handshake is the first message a client sends
+type Handshake struct {
+	Version uint16
+	Name    string
+}
+
+func (m *Handshake) Type() byte { return MessageTypeHandshake }
+
+func (m *Handshake) encode(w *writer) {
+	w.uint16(m.Version)
+	w.string(m.Name)
+}
+
+func (m *Handshake) decode(r *reader) {
+	m.Version = r.uint16()
+	m.Name = r.string()
+}
+
+// HandshakeAccept tells a client which entity is theirs and where they are
+type HandshakeAccept struct {
+	PlayerID uint32
+	Seed     uint64
+	X        float64
+	Y        float64
+}
+
+func (m *HandshakeAccept) Type() byte { return MessageTypeHandshakeAccept }
+
+func (m *HandshakeAccept) encode(w *writer) {
+	w.uint32(m.PlayerID)
+	w.uint64(m.Seed)
+	w.float64(m.X)
+	w.float64(m.Y)
+}
+
+func (m *HandshakeAccept) decode(r *reader) {
+	m.PlayerID = r.uint32()
+	m.Seed = r.uint64()
+	m.X = r.float64()
+	m.Y = r.float64()
+}
+
+// Disconnect is sent by the server right before it closes a connection
+type Disconnect struct {
+	Reason string
+}
+
+func (m *Disconnect) Type() byte { return MessageTypeDisconnect }
+
+func (m *Disconnect) encode(w *writer) {
+	w.string(m.Reason)
+}
+
+func (m *Disconnect) decode(r *reader) {
+	m.Reason = r.string()
+}
+
+type Block struct {
+	Type  byte
+	Frame byte
+}
+
+// ChunkData carries every block stack in a chunk, Blocks is indexed [y][x] with each stack going from the bottom up
+type ChunkData struct {
+	X      int32
+	Y      int32
+	W      byte
+	H      byte
+	Blocks [][][]Block
+}
+
+func (m *ChunkData) Type() byte { return MessageTypeChunkData }
+
+func (m *ChunkData) encode(w *writer) {
+	w.int32(m.X)
+	w.int32(m.Y)
+	w.uint8(m.W)
+	w.uint8(m.H)
+
+	for y := 0; y < int(m.H); y++ {
+		for x := 0; x < int(m.W); x++ {
+			var stack []Block
+			if y < len(m.Blocks) && x < len(m.Blocks[y]) {
+				stack = m.Blocks[y][x]
+			}
+
+			if len(stack) > 255 {
+				stack = stack[:255]
+			}
+
+			w.uint8(byte(len(stack)))
+			for _, b := range stack {
+				w.uint8(b.Type)
+				w.uint8(b.Frame)
+			}
+		}
+	}
+}
+
+func (m *ChunkData) decode(r *reader) {
+	m.X = r.int32()
+	m.Y = r.int32()
+	m.W = r.uint8()
+	m.H = r.uint8()
+
+	m.Blocks = make([][][]Block, m.H)
+	for y := 0; y < int(m.H); y++ {
+		m.Blocks[y] = make([][]Block, m.W)
+		for x := 0; x < int(m.W); x++ {
+			n := int(r.uint8())
+			if r.err != nil {
+				return
+			}
+
+			stack := make([]Block, n)
+			for i := 0; i < n; i++ {
+				stack[i].Type = r.uint8()
+				stack[i].Frame = r.uint8()
+			}
+			m.Blocks[y][x] = stack
+		}
+	}
+}
+
+// EntitySpawn introduces a player or floater to the client, item fields are only used by floaters and Name only by players
+type EntitySpawn struct {
+	ID             uint32
+	Kind           byte
+	Name           string
+	UnderlyingType byte
+	ItemType       byte
+	Frame          byte
+	X              float64
+	Y              float64
+}
+
+func (m *EntitySpawn) Type() byte { return MessageTypeEntitySpawn }
+
+func (m *EntitySpawn) encode(w *writer) {
+	w.uint32(m.ID)
+	w.uint8(m.Kind)
+	w.string(m.Name)
+	w.uint8(m.UnderlyingType)
+	w.uint8(m.ItemType)
+	w.uint8(m.Frame)
+	w.float64(m.X)
+	w.float64(m.Y)
+}
+
+func (m *EntitySpawn) decode(r *reader) {
+	m.ID = r.uint32()
+	m.Kind = r.uint8()
+	m.Name = r.string()
+	m.UnderlyingType = r.uint8()
+	m.ItemType = r.uint8()
+	m.Frame = r.uint8()
+	m.X = r.float64()
+	m.Y = r.float64()
+}
+
+type EntityMove struct {
+	ID        uint32
+	X         float64
+	Y         float64
+	Direction byte
+}
+
+func (m *EntityMove) Type() byte { return MessageTypeEntityMove }
+
+func (m *EntityMove) encode(w *writer) {
+	w.uint32(m.ID)
+	w.float64(m.X)
+	w.float64(m.Y)
+	w.uint8(m.Direction)
+}
+
+func (m *EntityMove) decode(r *reader) {
+	m.ID = r.uint32()
+	m.X = r.float64()
+	m.Y = r.float64()
+	m.Direction = r.uint8()
+}
+
+type EntityDespawn struct {
+	ID uint32
+}
+
+func (m *EntityDespawn) Type() byte { return MessageTypeEntityDespawn }
+
+func (m *EntityDespawn) encode(w *writer) {
+	w.uint32(m.ID)
+}
+
+func (m *EntityDespawn) decode(r *reader) {
+	m.ID = r.uint32()
+}
+
+// InventorySlot mirrors one slot of Player.Inventory, an Amount of 0 means the slot is empty
+type InventorySlot struct {
+	X              byte
+	Y              byte
+	UnderlyingType byte
+	ItemType       byte
+	Frame          byte
+	Amount         uint32
+	Durability     uint16 // hits left if the item is a tool
+	Name           string // the items custom name, empty if it doesn't have one
+}
+
+func (m *InventorySlot) Type() byte { return MessageTypeInventorySlot }
+
+func (m *InventorySlot) encode(w *writer) {
+	w.uint8(m.X)
+	w.uint8(m.Y)
+	w.uint8(m.UnderlyingType)
+	w.uint8(m.ItemType)
+	w.uint8(m.Frame)
+	w.uint32(m.Amount)
+	w.uint16(m.Durability)
+	w.string(m.Name)
+}
+
+func (m *InventorySlot) decode(r *reader) {
+	m.X = r.uint8()
+	m.Y = r.uint8()
+	m.UnderlyingType = r.uint8()
+	m.ItemType = r.uint8()
+	m.Frame = r.uint8()
+	m.Amount = r.uint32()
+	m.Durability = r.uint16()
+	m.Name = r.string()
+}
+
+// PlayerInput is the set of directions the client is currently holding
+type PlayerInput struct {
+	Directions []byte
+	Running    bool
+}
+
+func (m *PlayerInput) Type() byte { return MessageTypePlayerInput }
+
+func (m *PlayerInput) encode(w *writer) {
+	dirs := m.Directions
+	if len(dirs) > 255 {
+		dirs = dirs[:255]
+	}
+
+	w.uint8(byte(len(dirs)))
+	for _, d := range dirs {
+		w.uint8(d)
+	}
+	w.bool(m.Running)
+}
+
+func (m *PlayerInput) decode(r *reader) {
+	n := int(r.uint8())
+	m.Directions = make([]byte, 0, n)
+	for i := 0; i < n && r.err == nil; i++ {
+		m.Directions = append(m.Directions, r.uint8())
+	}
+	m.Running = r.bool()
+}
+
+// BlockPlace asks the server to place the item in hotbar Slot on top of the block at X, Y in the chunk. If the
+// block can be harvested it's harvested instead, a bed becomes the players spawn point and a consumable in the slot
+// is eaten.
+type BlockPlace struct {
+	ChunkX int32
+	ChunkY int32
+	X      byte
+	Y      byte
+	Slot   byte
+}
+
+func (m *BlockPlace) Type() byte { return MessageTypeBlockPlace }
+
+func (m *BlockPlace) encode(w *writer) {
+	w.int32(m.ChunkX)
+	w.int32(m.ChunkY)
+	w.uint8(m.X)
+	w.uint8(m.Y)
+	w.uint8(m.Slot)
+}
+
+func (m *BlockPlace) decode(r *reader) {
+	m.ChunkX = r.int32()
+	m.ChunkY = r.int32()
+	m.X = r.uint8()
+	m.Y = r.uint8()
+	m.Slot = r.uint8()
+}
+
+// BlockBreak asks the server to hit the top block at X, Y in the chunk
+type BlockBreak struct {
+	ChunkX int32
+	ChunkY int32
+	X      byte
+	Y      byte
+}
+
+func (m *BlockBreak) Type() byte { return MessageTypeBlockBreak }
+
+func (m *BlockBreak) encode(w *writer) {
+	w.int32(m.ChunkX)
+	w.int32(m.ChunkY)
+	w.uint8(m.X)
+	w.uint8(m.Y)
+}
+
+func (m *BlockBreak) decode(r *reader) {
+	m.ChunkX = r.int32()
+	m.ChunkY = r.int32()
+	m.X = r.uint8()
+	m.Y = r.uint8()
+}
+
+// PlayerStats are the players survival stats rounded up to whole numbers from 0 to 100
+type PlayerStats struct {
+	Health byte
+	Hunger byte
+	Thirst byte
+}
+
+func (m *PlayerStats) Type() byte { return MessageTypePlayerStats }
+
+func (m *PlayerStats) encode(w *writer) {
+	w.uint8(m.Health)
+	w.uint8(m.Hunger)
+	w.uint8(m.Thirst)
+}
+
+func (m *PlayerStats) decode(r *reader) {
+	m.Health = r.uint8()
+	m.Hunger = r.uint8()
+	m.Thirst = r.uint8()
+}
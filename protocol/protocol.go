@@ -0,0 +1,151 @@
+// Package protocol is the binary wire format spoken between skafos clients and servers.
+//
+// Every message is framed as a one byte message type, a big endian uint32 payload length and then the payload.
+package protocol
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// Version is sent in the handshake, a server only accepts clients speaking the same version
+const Version uint16 = 4
+
+// MaxPayloadSize stops a bad length prefix from making the decoder allocate forever
+const MaxPayloadSize = 1 << 20
+
+const (
+	MessageTypeHandshake       byte = 0
+	MessageTypeHandshakeAccept byte = 1
+	MessageTypeDisconnect      byte = 2
+	MessageTypeChunkData       byte = 3
+	MessageTypeEntitySpawn     byte = 4
+	MessageTypeEntityMove      byte = 5
+	MessageTypeEntityDespawn   byte = 6
+	MessageTypeInventorySlot   byte = 7
+	MessageTypePlayerInput     byte = 8
+	MessageTypeBlockPlace      byte = 9
+	MessageTypeBlockBreak      byte = 10
+	MessageTypePlayerStats     byte = 11
+)
+
+var (
+	ErrUnknownMessageType = errors.New("protocol: unknown message type")
+	ErrPayloadTooLarge    = errors.New("protocol: payload too large")
+)
+
+type Message interface {
+	Type() byte
+	encode(w *writer)
+	decode(r *reader)
+}
+
+// NewMessage returns an empty message for the given type so it can be decoded into
+func NewMessage(t byte) (Message, error) {
+	switch t {
+	case MessageTypeHandshake:
+		return &Handshake{}, nil
+	case MessageTypeHandshakeAccept:
+		return &HandshakeAccept{}, nil
+	case MessageTypeDisconnect:
+		return &Disconnect{}, nil
+	case MessageTypeChunkData:
+		return &ChunkData{}, nil
+	case MessageTypeEntitySpawn:
+		return &EntitySpawn{}, nil
+	case MessageTypeEntityMove:
+		return &EntityMove{}, nil
+	case MessageTypeEntityDespawn:
+		return &EntityDespawn{}, nil
+	case MessageTypeInventorySlot:
+		return &InventorySlot{}, nil
+	case MessageTypePlayerInput:
+		return &PlayerInput{}, nil
+	case MessageTypeBlockPlace:
+		return &BlockPlace{}, nil
+	case MessageTypeBlockBreak:
+		return &BlockBreak{}, nil
+	case MessageTypePlayerStats:
+		return &PlayerStats{}, nil
+	}
+
+	return nil, fmt.Errorf("%w %d", ErrUnknownMessageType, t)
+}
+
+type Encoder struct {
+	w *bufio.Writer
+}
+
+func NewEncoder(w io.Writer) *Encoder {
+	return &Encoder{
+		w: bufio.NewWriter(w),
+	}
+}
+
+// Encode writes a single framed message, nothing is sent until Flush is called
+func (e *Encoder) Encode(msg Message) error {
+	payload := &writer{}
+	msg.encode(payload)
+
+	if len(payload.buf) > MaxPayloadSize {
+		return ErrPayloadTooLarge
+	}
+
+	header := [5]byte{msg.Type()}
+	binary.BigEndian.PutUint32(header[1:], uint32(len(payload.buf)))
+
+	if _, err := e.w.Write(header[:]); err != nil {
+		return err
+	}
+
+	_, err := e.w.Write(payload.buf)
+	return err
+}
+
+func (e *Encoder) Flush() error {
+	return e.w.Flush()
+}
+
+type Decoder struct {
+	r *bufio.Reader
+}
+
+func NewDecoder(r io.Reader) *Decoder {
+	return &Decoder{
+		r: bufio.NewReader(r),
+	}
+}
+
+// Decode reads the next framed message
+func (d *Decoder) Decode() (Message, error) {
+	header := [5]byte{}
+	if _, err := io.ReadFull(d.r, header[:]); err != nil {
+		return nil, err
+	}
+
+	size := binary.BigEndian.Uint32(header[1:])
+	if size > MaxPayloadSize {
+		return nil, ErrPayloadTooLarge
+	}
+
+	msg, err := NewMessage(header[0])
+	if err != nil {
+		return nil, err
+	}
+
+	payload := make([]byte, size)
+	if _, err := io.ReadFull(d.r, payload); err != nil {
+		return nil, err
+	}
+
+	r := &reader{buf: payload}
+	msg.decode(r)
+	if r.err != nil {
+		return nil, fmt.Errorf("protocol: decoding message type %d: %w", header[0], r.err)
+	}
+
+	return msg, nil
+}
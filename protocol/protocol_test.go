@@ -0,0 +1,204 @@
+package protocol
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+	"net"
+	"reflect"
+	"testing"
+)
+
+// pipe returns an encoder and decoder talking to each other over an in-process connection
+func pipe(t *testing.T) (*Encoder, *Decoder) {
+	t.Helper()
+
+	client, server := net.Pipe()
+	t.Cleanup(func() {
+		client.Close()
+		server.Close()
+	})
+
+	return NewEncoder(client), NewDecoder(server)
+}
+
+// send encodes and flushes msgs on their own goroutine since writes to a pipe block until they're read
+func send(enc *Encoder, msgs ...Message) <-chan error {
+	done := make(chan error, 1)
+
+	go func() {
+		for _, msg := range msgs {
+			if err := enc.Encode(msg); err != nil {
+				done <- err
+				return
+			}
+		}
+		done <- enc.Flush()
+	}()
+
+	return done
+}
+
+// sendRaw writes b as it is, for frames the encoder would never make
+func sendRaw(t *testing.T, b []byte) *Decoder {
+	t.Helper()
+
+	client, server := net.Pipe()
+	t.Cleanup(func() {
+		client.Close()
+		server.Close()
+	})
+
+	go func() {
+		client.Write(b)
+		client.Close()
+	}()
+
+	return NewDecoder(server)
+}
+
+func TestRoundTrip(t *testing.T) {
+	tests := []struct {
+		name string
+		msg  Message
+	}{
+		{"handshake", &Handshake{Version: Version, Name: "jesse"}},
+		{"handshake with empty name", &Handshake{Version: 1, Name: ""}},
+		{"handshake accept", &HandshakeAccept{PlayerID: 42, Seed: math.MaxUint64, X: -12.5, Y: 1e9}},
+		{"disconnect", &Disconnect{Reason: "server closed"}},
+		{"chunk data", &ChunkData{
+			X: -3,
+			Y: math.MaxInt32,
+			W: 2,
+			H: 2,
+			Blocks: [][][]Block{
+				{{{Type: 0, Frame: 0}, {Type: 2, Frame: 1}}, {}},
+				{{}, {{Type: 9, Frame: 0}, {Type: 3, Frame: 0}, {Type: 255, Frame: 255}}},
+			},
+		}},
+		{"chunk data with only empty stacks", &ChunkData{
+			X: 0, Y: 0, W: 3, H: 1,
+			Blocks: [][][]Block{{{}, {}, {}}},
+		}},
+		{"entity spawn player", &EntitySpawn{ID: 1, Kind: EntityKindPlayer, Name: "jesse", X: 3, Y: -4}},
+		{"entity spawn floater", &EntitySpawn{ID: 2, Kind: EntityKindFloater, UnderlyingType: 1, ItemType: 5, Frame: 2, X: 0.25, Y: 100}},
+		{"entity move", &EntityMove{ID: 7, X: -1.5, Y: 2.75, Direction: 3}},
+		{"entity despawn", &EntityDespawn{ID: math.MaxUint32}},
+		{"inventory slot", &InventorySlot{X: 7, Y: 3, UnderlyingType: 3, ItemType: 10, Frame: 0, Amount: 1, Durability: 250, Name: "old faithful"}},
+		{"empty inventory slot", &InventorySlot{X: 0, Y: 0}},
+		{"player input", &PlayerInput{Directions: []byte{0, 2}, Running: true}},
+		{"player input with nothing held", &PlayerInput{Directions: []byte{}, Running: false}},
+		{"block place", &BlockPlace{ChunkX: -1, ChunkY: 2, X: 15, Y: 0, Slot: 7}},
+		{"block break", &BlockBreak{ChunkX: math.MinInt32, ChunkY: 0, X: 3, Y: 12}},
+		{"player stats", &PlayerStats{Health: 100, Hunger: 0, Thirst: 55}},
+	}
+
+	// every message type has to be covered
+	covered := map[byte]bool{}
+	for _, tt := range tests {
+		covered[tt.msg.Type()] = true
+	}
+	for typ := byte(0); typ <= MessageTypePlayerStats; typ++ {
+		if !covered[typ] {
+			t.Errorf("message type %d isn't round tripped", typ)
+		}
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			enc, dec := pipe(t)
+			done := send(enc, tt.msg)
+
+			got, err := dec.Decode()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := <-done; err != nil {
+				t.Fatal(err)
+			}
+
+			if !reflect.DeepEqual(got, tt.msg) {
+				t.Fatalf("got %#v, want %#v", got, tt.msg)
+			}
+		})
+	}
+}
+
+func TestRoundTripManyMessages(t *testing.T) {
+	enc, dec := pipe(t)
+
+	msgs := []Message{
+		&Handshake{Version: Version, Name: "a"},
+		&EntityMove{ID: 1, X: 1, Y: 2},
+		&EntityDespawn{ID: 1},
+		&PlayerStats{Health: 1, Hunger: 2, Thirst: 3},
+	}
+	done := send(enc, msgs...)
+
+	for _, want := range msgs {
+		got, err := dec.Decode()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Fatalf("got %#v, want %#v", got, want)
+		}
+	}
+
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDecodeOversizedPayload(t *testing.T) {
+	header := [5]byte{MessageTypeChunkData}
+	binary.BigEndian.PutUint32(header[1:], MaxPayloadSize+1)
+
+	_, err := sendRaw(t, header[:]).Decode()
+	if !errors.Is(err, ErrPayloadTooLarge) {
+		t.Fatalf("got error %v, want %v", err, ErrPayloadTooLarge)
+	}
+}
+
+func TestEncodeOversizedPayload(t *testing.T) {
+	// 255 by 255 stacks of 10 blocks is more than MaxPayloadSize
+	msg := &ChunkData{W: 255, H: 255, Blocks: make([][][]Block, 255)}
+	for y := range msg.Blocks {
+		msg.Blocks[y] = make([][]Block, 255)
+		for x := range msg.Blocks[y] {
+			msg.Blocks[y][x] = make([]Block, 10)
+		}
+	}
+
+	if err := NewEncoder(io.Discard).Encode(msg); !errors.Is(err, ErrPayloadTooLarge) {
+		t.Fatalf("got error %v, want %v", err, ErrPayloadTooLarge)
+	}
+}
+
+func TestDecodeUnknownMessageType(t *testing.T) {
+	header := [5]byte{MessageTypePlayerStats + 1}
+
+	_, err := sendRaw(t, header[:]).Decode()
+	if !errors.Is(err, ErrUnknownMessageType) {
+		t.Fatalf("got error %v, want %v", err, ErrUnknownMessageType)
+	}
+}
+
+func TestDecodeTruncatedPayload(t *testing.T) {
+	// a handshake whose payload is one byte short of its version
+	frame := []byte{MessageTypeHandshake, 0, 0, 0, 1, 0}
+
+	if _, err := sendRaw(t, frame).Decode(); err == nil {
+		t.Fatal("decoding a truncated handshake didn't fail")
+	}
+}
+
+func TestDecodeShortPayloadInsideFrame(t *testing.T) {
+	// the frame is complete but says the name is longer than the payload
+	frame := []byte{MessageTypeHandshake, 0, 0, 0, 4, 0, byte(Version), 0, 9}
+
+	if _, err := sendRaw(t, frame).Decode(); err == nil {
+		t.Fatal("decoding a handshake with a name longer than its payload didn't fail")
+	}
+}
@@ -0,0 +1,84 @@
+package server
+
+import (
+	"github.com/gopxl/pixel/v2"
+	"github.com/jessehorne/skafos/game"
+	"github.com/jessehorne/skafos/protocol"
+	"net"
+	"time"
+)
+
+const writeTimeout = 5 * time.Second
+
+type Client struct {
+	ID            uint32
+	Name          string
+	Conn          net.Conn
+	Player        *game.Player
+	Running       bool
+	SentChunks    map[game.IntVec]bool                   // chunks this client has already been sent
+	SentInventory map[game.IntVec]protocol.InventorySlot // the inventory as the client last saw it
+	KnownEntities map[uint32]pixel.Vec                   // entities the client has been told about and where it thinks they are
+	SentStats     *protocol.PlayerStats                  // the stats as the client last saw them
+	Send          chan protocol.Message
+}
+
+func NewClient(id uint32, conn net.Conn) *Client {
+	return &Client{
+		ID:            id,
+		Conn:          conn,
+		SentChunks:    map[game.IntVec]bool{},
+		SentInventory: map[game.IntVec]protocol.InventorySlot{},
+		KnownEntities: map[uint32]pixel.Vec{},
+		Send:          make(chan protocol.Message, 1024),
+	}
+}
+
+// readLoop decodes messages from the connection and hands them to the server until the connection closes
+func (c *Client) readLoop(s *Server) {
+	dec := protocol.NewDecoder(c.Conn)
+
+	for {
+		msg, err := dec.Decode()
+		if err != nil {
+			s.disconnects <- c
+			return
+		}
+
+		s.incoming <- clientMessage{Client: c, Message: msg}
+	}
+}
+
+// writeLoop encodes queued messages to the connection so a slow client never blocks the simulation, the
+// connection is closed once Send is closed and everything left in it has been written
+func (c *Client) writeLoop() {
+	defer c.Conn.Close()
+
+	enc := protocol.NewEncoder(c.Conn)
+
+	for msg := range c.Send {
+		if err := enc.Encode(msg); err != nil {
+			return
+		}
+
+		// only flush once everything queued has been written
+		if len(c.Send) == 0 {
+			c.Conn.SetWriteDeadline(time.Now().Add(writeTimeout))
+			if err := enc.Flush(); err != nil {
+				return
+			}
+		}
+	}
+
+	c.Conn.SetWriteDeadline(time.Now().Add(writeTimeout))
+	enc.Flush()
+}
+
+// Queue sends msg to the client, dropping the client if it can't keep up
+func (c *Client) Queue(msg protocol.Message) {
+	select {
+	case c.Send <- msg:
+	default:
+		c.Conn.Close()
+	}
+}
@@ -0,0 +1,36 @@
+package server
+
+import (
+	"flag"
+	"github.com/jessehorne/skafos/game"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// Main runs a server for the world named in args until it's interrupted, then saves it. Nothing it uses needs a
+// window or opengl so it can be built on its own, see cmd/skafos-server.
+func Main(args []string) {
+	flags := flag.NewFlagSet("server", flag.ExitOnError)
+	addr := flags.String("addr", ":7777", "address to listen on")
+	world := flags.String("world", "test", "name of the world to host")
+	generator := flags.String("generator", game.DefaultWorldGenerator, "world generator to create the world with if it doesn't exist yet")
+	flags.Parse(args)
+
+	s, err := NewServer(*addr, *world, *generator)
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-interrupt
+		s.Stop()
+	}()
+
+	if err := s.Run(); err != nil {
+		log.Fatalln(err)
+	}
+}
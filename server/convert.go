@@ -0,0 +1,68 @@
+package server
+
+import (
+	"github.com/jessehorne/skafos/game"
+	"github.com/jessehorne/skafos/protocol"
+	"math"
+)
+
+func ChunkMessage(c *game.Chunk) *protocol.ChunkData {
+	msg := &protocol.ChunkData{
+		X:      int32(c.X),
+		Y:      int32(c.Y),
+		W:      byte(c.W),
+		H:      byte(c.H),
+		Blocks: make([][][]protocol.Block, c.H),
+	}
+
+	for ty := 0; ty < c.H; ty++ {
+		msg.Blocks[ty] = make([][]protocol.Block, c.W)
+		for tx := 0; tx < c.W; tx++ {
+			for _, b := range c.Blocks[ty][tx] {
+				msg.Blocks[ty][tx] = append(msg.Blocks[ty][tx], protocol.Block{Type: b.Type, Frame: b.Frame})
+			}
+		}
+	}
+
+	return msg
+}
+
+func FloaterSpawnMessage(id uint32, f *game.Floater) *protocol.EntitySpawn {
+	return &protocol.EntitySpawn{
+		ID:             id,
+		Kind:           protocol.EntityKindFloater,
+		UnderlyingType: f.UnderlyingType,
+		ItemType:       f.ItemType,
+		Frame:          f.Frame,
+		X:              f.Position.X,
+		Y:              f.Position.Y,
+	}
+}
+
+// InventorySlotMessage describes the inventory slot at x, y, item can be nil for an empty slot
+func InventorySlotMessage(x, y int, item *game.InventoryItem) *protocol.InventorySlot {
+	msg := &protocol.InventorySlot{
+		X: byte(x),
+		Y: byte(y),
+	}
+
+	if item != nil && item.Amount > 0 {
+		msg.UnderlyingType = item.UnderlyingType
+		msg.ItemType = item.ItemType
+		msg.Frame = item.Frame
+		msg.Amount = uint32(item.Amount)
+		msg.Durability = uint16(item.Meta.Durability)
+		msg.Name = item.Meta.Name
+	}
+
+	return msg
+}
+
+// PlayerStatsMessage describes the players stats, they're rounded up so a stat only shows 0 once it's really empty
+func PlayerStatsMessage(p *game.Player) *protocol.PlayerStats {
+	return &protocol.PlayerStats{
+		Health: byte(math.Ceil(p.Health)),
+		Hunger: byte(math.Ceil(p.Hunger)),
+		Thirst: byte(math.Ceil(p.Thirst)),
+	}
+}
@@ -0,0 +1,545 @@
+package server
+
+import (
+	"errors"
+	"github.com/gopxl/pixel/v2"
+	"github.com/jessehorne/skafos/game"
+	"github.com/jessehorne/skafos/protocol"
+	"log"
+	"math/rand/v2"
+	"net"
+	"regexp"
+	"time"
+)
+
+var validName = regexp.MustCompile(`^[a-zA-Z0-9_-]{1,16}$`)
+
+type clientMessage struct {
+	Client  *Client
+	Message protocol.Message
+}
+
+// Server runs a world without a window and is the authority for everything that happens in it
+type Server struct {
+	Addr     string
+	TickRate float64 // simulation ticks per second
+	Map      *game.Map
+
+	clients      map[uint32]*Client
+	floaterIDs   map[*game.Floater]uint32
+	nextEntityID uint32
+	incoming     chan clientMessage
+	connects     chan net.Conn
+	disconnects  chan *Client
+	stop         chan struct{}
+}
+
+// NewServer loads the world called worldName or creates it with the world generator called generator
+func NewServer(addr, worldName, generator string) (*Server, error) {
+	s, err := game.LoadAssets()
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := game.LoadWorldData(worldName)
+	if err != nil {
+		return nil, err
+	}
+
+	seed := rand.Uint64()
+	if data != nil {
+		seed = data.Seed
+		generator = data.Generator
+	}
+
+	m, err := game.NewMap(worldName, seed, generator, s)
+	if err != nil {
+		return nil, err
+	}
+
+	if data != nil {
+		m.Spawn = data.Spawn
+		game.LoadFloaters(data.Floaters)
+	}
+
+	return &Server{
+		Addr:        addr,
+		TickRate:    20,
+		Map:         m,
+		clients:     map[uint32]*Client{},
+		floaterIDs:  map[*game.Floater]uint32{},
+		incoming:    make(chan clientMessage, 1024),
+		connects:    make(chan net.Conn),
+		disconnects: make(chan *Client, 16),
+		stop:        make(chan struct{}),
+	}, nil
+}
+
+// Run listens for clients and ticks the world until Stop is called
+func (s *Server) Run() error {
+	ln, err := net.Listen("tcp", s.Addr)
+	if err != nil {
+		return err
+	}
+	defer ln.Close()
+
+	log.Printf("server listening on %s (world %q, seed %d)\n", ln.Addr(), s.Map.Name, s.Map.Seed)
+
+	go s.accept(ln)
+
+	ticker := time.NewTicker(time.Duration(float64(time.Second) / s.TickRate))
+	defer ticker.Stop()
+
+	// the world is ticked at a fixed rate like in the game so a stall, like saving chunks, can't move players through
+	// trees with one big tick
+	timestep := game.NewFixedTimestep(1/s.TickRate, game.MaxCatchUpTicks)
+	last := time.Now()
+	for {
+		select {
+		case <-s.stop:
+			for _, c := range s.clients {
+				s.disconnect(c, "server closed")
+			}
+			return s.Save()
+		case conn := <-s.connects:
+			c := NewClient(s.newEntityID(), conn)
+			s.clients[c.ID] = c
+
+			go c.writeLoop()
+			go c.readLoop(s)
+		case c := <-s.disconnects:
+			s.removeClient(c)
+		case <-ticker.C:
+			elapsed := time.Since(last).Seconds()
+			last = time.Now()
+			timestep.Advance(elapsed, s.Tick)
+		}
+	}
+}
+
+func (s *Server) Stop() {
+	close(s.stop)
+}
+
+func (s *Server) accept(ln net.Listener) {
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return
+			}
+			log.Println(err)
+			continue
+		}
+
+		select {
+		case s.connects <- conn:
+		case <-s.stop:
+			conn.Close()
+			return
+		}
+	}
+}
+
+// newEntityID hands out ids shared by players and floaters, it's only called from the tick goroutine
+func (s *Server) newEntityID() uint32 {
+	s.nextEntityID++
+	return s.nextEntityID
+}
+
+// Tick advances the world by dt seconds
+func (s *Server) Tick(dt float64) {
+	// handle everything the clients sent since the last tick
+	for len(s.incoming) > 0 {
+		cm := <-s.incoming
+		if _, ok := s.clients[cm.Client.ID]; !ok {
+			continue
+		}
+		s.handleMessage(cm.Client, cm.Message)
+	}
+
+	centers := []pixel.Vec{}
+	for _, c := range s.clients {
+		if c.Player == nil {
+			continue
+		}
+
+		if c.Running {
+			c.Player.WalkingOrRunning = game.PlayerRunning
+		} else {
+			c.Player.WalkingOrRunning = game.PlayerWalking
+		}
+		c.Player.UpdateMovement(dt)
+		c.Player.UpdateStats(dt)
+
+		if c.Player.Health <= 0 {
+			c.Player.Die()
+			c.Player.Respawn(s.Map.RespawnPosition(c.Player))
+			log.Printf("%s died\n", c.Name)
+		}
+
+		s.Map.ChunkPosition = c.Player.GetChunkPosition()
+		s.Map.GenerateChunksAroundPlayer()
+		s.sendChunksAround(c)
+
+		centers = append(centers, s.Map.ChunkPosition)
+	}
+
+	// with nobody online whatever is loaded stays loaded until someone joins
+	s.Map.UnloadDistantChunks(centers...)
+
+	for _, pos := range s.Map.TickBlocks(dt) {
+		s.broadcastChunk(pos)
+	}
+	s.Map.TickBlockEntities(dt)
+	game.UpdateFloaters(dt)
+	game.CheckCollisions()
+
+	s.syncEntities()
+
+	for _, c := range s.clients {
+		if c.Player == nil {
+			continue
+		}
+
+		if c.Player.InventoryChanged {
+			c.Player.InventoryChanged = false
+			s.syncInventory(c)
+		}
+
+		s.syncStats(c)
+	}
+}
+
+func (s *Server) handleMessage(c *Client, msg protocol.Message) {
+	// nothing but a handshake is allowed before the client has a player
+	if c.Player == nil {
+		if m, ok := msg.(*protocol.Handshake); ok {
+			s.handleHandshake(c, m)
+		} else {
+			s.disconnect(c, "expected handshake")
+		}
+		return
+	}
+
+	switch m := msg.(type) {
+	case *protocol.PlayerInput:
+		c.Player.MovementDirections = []byte{}
+		for _, d := range m.Directions {
+			if d <= game.PlayerDirectionRight {
+				c.Player.AddMovementDirection(d)
+			}
+		}
+		c.Running = m.Running
+	case *protocol.BlockPlace:
+		s.handleBlockPlace(c, m)
+	case *protocol.BlockBreak:
+		s.handleBlockBreak(c, m)
+	}
+}
+
+func (s *Server) handleHandshake(c *Client, msg *protocol.Handshake) {
+	if msg.Version != protocol.Version {
+		s.disconnect(c, "protocol version mismatch")
+		return
+	}
+
+	if !validName.MatchString(msg.Name) {
+		s.disconnect(c, "invalid name")
+		return
+	}
+
+	for _, other := range s.clients {
+		if other.Name == msg.Name {
+			s.disconnect(c, "name already in use")
+			return
+		}
+	}
+
+	p, err := game.NewPlayer()
+	if err != nil {
+		log.Println(err)
+		s.disconnect(c, "could not create player")
+		return
+	}
+
+	data, err := s.Map.LoadPlayer(msg.Name)
+	if err != nil {
+		log.Println(err)
+	}
+	if data != nil {
+		p.LoadData(*data)
+	}
+
+	c.Name = msg.Name
+	c.Player = p
+
+	// there's no respawn screen over the network so players that left while dead come back alive
+	if p.Dead {
+		p.Respawn(s.Map.RespawnPosition(p))
+	} else {
+		game.AddCollideable(p)
+	}
+
+	log.Printf("%s joined as %d\n", c.Name, c.ID)
+
+	c.Queue(&protocol.HandshakeAccept{
+		PlayerID: c.ID,
+		Seed:     s.Map.Seed,
+		X:        p.Position.X,
+		Y:        p.Position.Y,
+	})
+	s.syncInventory(c)
+}
+
+func (s *Server) handleBlockPlace(c *Client, msg *protocol.BlockPlace) {
+	p := c.Player
+
+	if int(msg.Slot) >= len(p.Inventory[0]) {
+		return
+	}
+
+	item := p.Inventory[0][msg.Slot]
+
+	chunk := game.NewIntVec(int(msg.ChunkX), int(msg.ChunkY))
+	coords := game.NewIntVec(int(msg.X), int(msg.Y))
+
+	// this works the same way as a right click on the client, the target block is harvested or slept in before the
+	// held item is used
+	blockCoords := pixel.V(float64(chunk.X*16+coords.X), float64(chunk.Y*16+coords.Y))
+	if p.CanReach(blockCoords) && s.Map.HarvestBlock(chunk, coords) {
+		s.broadcastChunk(chunk)
+		return
+	}
+
+	if p.CanReach(blockCoords) && p.SetSpawnAt(s.Map, game.NewIntVec(int(blockCoords.X), int(blockCoords.Y))) {
+		return
+	}
+
+	if p.CanReach(blockCoords) && p.UseWaterAt(s.Map, game.NewIntVec(int(blockCoords.X), int(blockCoords.Y))) {
+		return
+	}
+
+	if item == nil {
+		return
+	}
+
+	if item.UnderlyingType == game.UnderlyingTypeConsumable {
+		p.Eat(item)
+		return
+	}
+
+	if !p.CanReach(blockCoords) {
+		return
+	}
+
+	if p.PlaceBlockAt(s.Map, chunk, coords, item) {
+		s.broadcastChunk(chunk)
+	}
+}
+
+func (s *Server) handleBlockBreak(c *Client, msg *protocol.BlockBreak) {
+	chunk := game.NewIntVec(int(msg.ChunkX), int(msg.ChunkY))
+	coords := game.NewIntVec(int(msg.X), int(msg.Y))
+
+	blockCoords := pixel.V(float64(chunk.X*16+coords.X), float64(chunk.Y*16+coords.Y))
+	if !c.Player.CanReach(blockCoords) {
+		return
+	}
+
+	if c.Player.HitBlockAt(s.Map, chunk, coords) {
+		s.broadcastChunk(chunk)
+	}
+}
+
+// disconnect tells the client why it's being dropped and then drops it
+func (s *Server) disconnect(c *Client, reason string) {
+	c.Queue(&protocol.Disconnect{Reason: reason})
+	s.removeClient(c)
+}
+
+func (s *Server) removeClient(c *Client) {
+	if _, ok := s.clients[c.ID]; !ok {
+		return
+	}
+
+	delete(s.clients, c.ID)
+
+	// the write loop closes the connection once it has sent everything left in the channel
+	close(c.Send)
+
+	if c.Player != nil {
+		game.RemoveCollideable(c.Player)
+
+		if err := s.Map.SavePlayer(c.Name, c.Player.ToData()); err != nil {
+			log.Println(err)
+		}
+
+		log.Printf("%s left\n", c.Name)
+	}
+}
+
+// sendChunksAround sends the client every chunk in its draw radius it hasn't already been sent
+func (s *Server) sendChunksAround(c *Client) {
+	center := c.Player.GetChunkPosition()
+	r := s.Map.DrawRadius
+
+	for y := center.Y - r; y < center.Y+r; y++ {
+		for x := center.X - r; x < center.X+r; x++ {
+			pos := game.NewIntVec(int(x), int(y))
+			if c.SentChunks[pos] {
+				continue
+			}
+
+			chunk := s.Map.GetChunk(pos.X, pos.Y)
+			if chunk == nil {
+				continue
+			}
+
+			c.Queue(ChunkMessage(chunk))
+			c.SentChunks[pos] = true
+		}
+	}
+}
+
+// broadcastChunk resends a changed chunk to every client that has it
+func (s *Server) broadcastChunk(pos game.IntVec) {
+	chunk := s.Map.GetChunk(pos.X, pos.Y)
+	if chunk == nil {
+		return
+	}
+
+	msg := ChunkMessage(chunk)
+	for _, c := range s.clients {
+		if c.SentChunks[pos] {
+			c.Queue(msg)
+		}
+	}
+}
+
+// syncEntities spawns, moves and despawns players and floaters on every client so they match the server
+func (s *Server) syncEntities() {
+	spawns := map[uint32]*protocol.EntitySpawn{}
+	positions := map[uint32]pixel.Vec{}
+	directions := map[uint32]byte{}
+
+	for _, c := range s.clients {
+		if c.Player == nil {
+			continue
+		}
+
+		spawns[c.ID] = &protocol.EntitySpawn{
+			ID:   c.ID,
+			Kind: protocol.EntityKindPlayer,
+			Name: c.Name,
+			X:    c.Player.Position.X,
+			Y:    c.Player.Position.Y,
+		}
+		positions[c.ID] = c.Player.Position
+		directions[c.ID] = c.Player.MovementDirection
+	}
+
+	alive := map[*game.Floater]bool{}
+	for _, f := range game.Floaters {
+		if f.Deleted {
+			continue
+		}
+		alive[f] = true
+
+		id, ok := s.floaterIDs[f]
+		if !ok {
+			id = s.newEntityID()
+			s.floaterIDs[f] = id
+		}
+
+		spawns[id] = FloaterSpawnMessage(id, f)
+		positions[id] = f.Position
+	}
+
+	for f := range s.floaterIDs {
+		if !alive[f] {
+			delete(s.floaterIDs, f)
+		}
+	}
+
+	for _, c := range s.clients {
+		if c.Player == nil {
+			continue
+		}
+
+		for id, known := range c.KnownEntities {
+			if _, ok := positions[id]; !ok {
+				c.Queue(&protocol.EntityDespawn{ID: id})
+				delete(c.KnownEntities, id)
+			} else if known != positions[id] {
+				pos := positions[id]
+				c.Queue(&protocol.EntityMove{ID: id, X: pos.X, Y: pos.Y, Direction: directions[id]})
+				c.KnownEntities[id] = pos
+			}
+		}
+
+		for id, spawn := range spawns {
+			if _, ok := c.KnownEntities[id]; !ok {
+				c.Queue(spawn)
+				c.KnownEntities[id] = positions[id]
+			}
+		}
+	}
+}
+
+// syncInventory sends the client every inventory slot that changed since it was last synced
+func (s *Server) syncInventory(c *Client) {
+	inv := c.Player.Inventory
+
+	for y := 0; y < len(inv); y++ {
+		for x := 0; x < len(inv[y]); x++ {
+			pos := game.NewIntVec(x, y)
+			slot := InventorySlotMessage(x, y, inv[y][x])
+
+			if sent, ok := c.SentInventory[pos]; ok && sent == *slot {
+				continue
+			}
+
+			c.Queue(slot)
+			c.SentInventory[pos] = *slot
+		}
+	}
+}
+
+// syncStats sends the players stats if they have changed since the client last saw them
+func (s *Server) syncStats(c *Client) {
+	stats := PlayerStatsMessage(c.Player)
+	if c.SentStats != nil && *c.SentStats == *stats {
+		return
+	}
+
+	c.Queue(stats)
+	c.SentStats = stats
+}
+
+// Save writes the world, its chunks and every connected player to disk
+func (s *Server) Save() error {
+	data := game.WorldData{
+		Name:      s.Map.Name,
+		Seed:      s.Map.Seed,
+		Generator: s.Map.Generator.Name(),
+		Spawn:     s.Map.Spawn,
+		Floaters:  game.FloatersToData(),
+	}
+
+	if err := game.SaveWorldData(data); err != nil {
+		return err
+	}
+
+	for _, c := range s.clients {
+		if c.Player == nil {
+			continue
+		}
+
+		if err := s.Map.SavePlayer(c.Name, c.Player.ToData()); err != nil {
+			return err
+		}
+	}
+
+	return s.Map.Save()
+}